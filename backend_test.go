@@ -0,0 +1,65 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// BenchmarkBackendAllocs compares the allocations of the convolution and the recursion for the same problems,
+// reporting testing.AllocsPerRun of one computation as allocs/run next to the usual bytes per operation
+func BenchmarkBackendAllocs(b *testing.B) {
+	for _, tc := range []struct{ n, m int }{{2000, 5}, {50000, 5}, {5000, 7}} {
+		for _, name := range []string{"convolution", "recursion"} {
+			compute := backends[name]
+			b.Run(fmt.Sprintf("%s/n=%d/m=%d", name, tc.n, tc.m), func(b *testing.B) {
+				b.ReportAllocs()
+				b.StopTimer()
+				b.ReportMetric(testing.AllocsPerRun(1, func() { compute(tc.n, tc.m) }), "allocs/run")
+				b.StartTimer()
+				for i := 0; i < b.N; i++ {
+					compute(tc.n, tc.m)
+				}
+			})
+		}
+	}
+}
+
+func TestCountTargetBackendsAgree(t *testing.T) {
+	names := []string{"auto"}
+	for name := range backends {
+		names = append(names, name)
+	}
+	for _, tc := range []struct{ n, m int }{{2000, 5}, {100, 7}, {12, 12}, {0, 3}, {21, 1}} {
+		want := ResidueDistribution(tc.n, tc.m).Totals
+		for target := 0; target < tc.m; target++ {
+			for _, name := range names {
+				got, err := CountTarget(name, tc.n, tc.m, target)
+				if err != nil {
+					t.Fatalf("%s backend, n = %d, m = %d, target %d: %v", name, tc.n, tc.m, target, err)
+				}
+				if got.Cmp(want[target]) != 0 {
+					t.Errorf("%s backend, n = %d, m = %d, target %d: %v, want %v", name, tc.n, tc.m, target, got, want[target])
+				}
+			}
+		}
+	}
+
+	for _, tc := range []struct {
+		backend      string
+		n, m, target int
+		want         error
+	}{
+		{"convolution", 10, 5, 5, ErrResidueOutOfRange},
+		{"recursion", 10, 5, -1, ErrResidueOutOfRange},
+		{"auto", 10, 0, 0, ErrInvalidModulus},
+		{"auto", -1, 5, 0, ErrNegativeN},
+	} {
+		if _, err := CountTarget(tc.backend, tc.n, tc.m, tc.target); !errors.Is(err, tc.want) {
+			t.Errorf("CountTarget(%q, %d, %d, %d) gave %v, want %v", tc.backend, tc.n, tc.m, tc.target, err, tc.want)
+		}
+	}
+	if _, err := CountTarget("abacus", 10, 5, 0); err == nil {
+		t.Error("an unknown backend was accepted")
+	}
+}
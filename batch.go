@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// runBatch reads lines of n,m,r from the CSV file at path and writes each line to w with the number of subsets
+// of {1,...,n} whose sum is r modulo m appended. A first line of n,m,r is taken as a header and gets a count
+// column. Malformed lines are reported to errw with their line number and skipped, and blank lines are ignored.
+// Each line is parsed on its own, so a stray quote only spoils its line instead of running on into the next ones.
+// The distribution for each distinct (n, m) is only computed once, since it has the counts for every r. The named
+// backend, "auto" or one of the backends, computes each distribution, and with noEmpty the empty subset is left
+// out of the counts. An unknown backend is an error before anything is read
+func runBatch(w, errw io.Writer, path, backend string, noEmpty bool) error {
+	if _, _, err := selectBackend(backend, 0, 1); err != nil {
+		return err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	out := csv.NewWriter(w)
+	cache := make(map[[2]int]*Result)
+
+	for line, first := 1, true; scanner.Scan(); line++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		record, err := parseBatchLine(scanner.Text())
+		header := first && err == nil && len(record) == 3 && strings.EqualFold(strings.Join(record, ","), "n,m,r")
+		first = false
+		if header {
+			out.Write(append(record, "count"))
+			continue
+		}
+
+		var n, m, r int
+		if err == nil {
+			n, m, r, err = parseBatchRecord(record)
+		}
+		if err != nil {
+			fmt.Fprintf(errw, "%s:%d: %v\n", path, line, err)
+			continue
+		}
+		res, ok := cache[[2]int{n, m}]
+		if !ok {
+			_, compute, err := selectBackend(backend, n, m)
+			if err != nil {
+				return err
+			}
+			res = compute(n, m)
+			if noEmpty {
+				res = res.WithoutEmpty()
+			}
+			cache[[2]int{n, m}] = res
+		}
+		out.Write([]string{strconv.Itoa(n), strconv.Itoa(m), strconv.Itoa(r), res.Totals[r].String()})
+	}
+	out.Flush()
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return out.Error()
+}
+
+// parseBatchLine splits one line of a batch file into its CSV fields
+func parseBatchLine(line string) ([]string, error) {
+	in := csv.NewReader(strings.NewReader(line))
+	in.FieldsPerRecord = -1
+	in.TrimLeadingSpace = true
+	record, err := in.Read()
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		// its line and column are within this line alone, and the caller reports the line number in the file
+		return nil, parseErr.Err
+	}
+	return record, err
+}
+
+// parseBatchRecord parses and validates one n,m,r line of a batch file
+func parseBatchRecord(record []string) (n, m, r int, err error) {
+	if len(record) != 3 {
+		return 0, 0, 0, fmt.Errorf("expected 3 fields n,m,r, got %d", len(record))
+	}
+	var v [3]int
+	for i, field := range record {
+		if v[i], err = strconv.Atoi(strings.TrimSpace(field)); err != nil {
+			return 0, 0, 0, fmt.Errorf("bad %c: %w", "nmr"[i], err)
+		}
+	}
+	n, m, r = v[0], v[1], v[2]
+	for _, err := range []error{validateN(n), validateModulus(m)} {
+		if err != nil {
+			return 0, 0, 0, err
+		}
+	}
+	if r < 0 || r >= m {
+		return 0, 0, 0, fmt.Errorf("%w: r = %d is not in [0, %d)", ErrResidueOutOfRange, r, m)
+	}
+	return n, m, r, nil
+}
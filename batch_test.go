@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestRunBatch(t *testing.T) {
+	path := filepath.Join("testdata", "batch.csv")
+	for _, noEmpty := range []bool{false, true} {
+		var out, errs bytes.Buffer
+		if err := runBatch(&out, &errs, path, "auto", noEmpty); err != nil {
+			t.Fatal(err)
+		}
+		// the stray quote on line 6 must not swallow the lines after it
+		if !noEmpty {
+			checkGolden(t, "batch.golden", out.Bytes())
+			checkGolden(t, "batch-errors.golden", errs.Bytes())
+		}
+
+		table := out.String()
+		records, err := csv.NewReader(&out).ReadAll()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(records) < 2 {
+			t.Fatalf("only %d output lines", len(records))
+		}
+		for _, record := range records[1:] {
+			var v [4]int
+			for i, field := range record {
+				if v[i], err = strconv.Atoi(field); err != nil {
+					t.Fatalf("bad output line %q: %v", record, err)
+				}
+			}
+			want := bruteForceDistribution(oneTo(v[0]), v[1])[v[2]].Int64()
+			if noEmpty && v[2] == 0 {
+				want--
+			}
+			if int64(v[3]) != want {
+				t.Errorf("noEmpty = %v: %q has the wrong count, want %d", noEmpty, record, want)
+			}
+		}
+
+		// every backend writes the same table
+		for name := range backends {
+			var other bytes.Buffer
+			if err := runBatch(&other, &bytes.Buffer{}, path, name, noEmpty); err != nil {
+				t.Fatal(err)
+			}
+			if other.String() != table {
+				t.Errorf("%s backend, noEmpty = %v: wrote\n%s\nwant\n%s", name, noEmpty, other.String(), table)
+			}
+		}
+	}
+}
+
+func TestRunBatchUnknownBackend(t *testing.T) {
+	// the backend is checked before any line is read, so it fails even for a file without a valid line
+	invalid := filepath.Join(t.TempDir(), "invalid.csv")
+	if err := os.WriteFile(invalid, []byte("n,m,r\n7,0,0\n5,\"3,0\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{invalid, filepath.Join(t.TempDir(), "missing.csv")} {
+		var out, errs bytes.Buffer
+		if err := runBatch(&out, &errs, path, "abacus", false); err == nil {
+			t.Errorf("%s: an unknown backend was accepted", path)
+		}
+		if out.Len() != 0 || errs.Len() != 0 {
+			t.Errorf("%s: wrote %q and %q before rejecting the backend", path, out.String(), errs.String())
+		}
+	}
+}
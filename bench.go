@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// parseIntList parses a comma separated list of integers such as "100,1000,2000"
+func parseIntList(s string) ([]int, error) {
+	var list []int
+	for _, field := range strings.Split(s, ",") {
+		v, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, v)
+	}
+	return list, nil
+}
+
+// runBench implements the bench subcommand. It times the computation for every (n, m) in the grid
+// given by -n and -m and prints a table of the timings and the number of digits of each result
+func runBench(w io.Writer, args []string) error {
+	fs := flag.NewFlagSet("bench", flag.ExitOnError)
+	nList := fs.String("n", "100,1000,2000", "comma separated values of n")
+	mList := fs.String("m", "3,5,7", "comma separated moduli")
+	backend := fs.String("backend", "auto", "backend to time")
+	timeout := fs.Duration("timeout", 10*time.Second, "give up on a cell after this long")
+	fs.Parse(args)
+
+	ns, err := parseIntList(*nList)
+	if err != nil {
+		return fmt.Errorf("bad -n: %w", err)
+	}
+	ms, err := parseIntList(*mList)
+	if err != nil {
+		return fmt.Errorf("bad -m: %w", err)
+	}
+	for _, n := range ns {
+		if err := validateN(n); err != nil {
+			return err
+		}
+	}
+	for _, m := range ms {
+		if err := validateModulus(m); err != nil {
+			return err
+		}
+	}
+	if _, _, err := selectBackend(*backend, 0, 1); err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "n\tm\telapsed\tdigits\t")
+	for _, n := range ns {
+		for _, m := range ms {
+			ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+			start := time.Now()
+			res, err := computeContext(ctx, *backend, n, m)
+			elapsed := time.Since(start)
+			cancel()
+
+			switch {
+			case errors.Is(err, context.DeadlineExceeded):
+				fmt.Fprintf(tw, "%d\t%d\t>%v\tTIMEOUT\t\n", n, m, *timeout)
+			case err != nil:
+				return err
+			default:
+				fmt.Fprintf(tw, "%d\t%d\t%v\t%d\t\n", n, m, elapsed.Round(time.Microsecond), len(res.Totals[0].String()))
+			}
+		}
+	}
+	return tw.Flush()
+}
+
+// runTrials computes the distribution with the named backend 'trials' times and prints the minimum, median
+// and maximum wall time. Unless warm is set the binomial cache is cleared before every trial, so each one
+// is timed from cold. The recursion is the backend that reads the cache, when -no-cache is not given; the
+// convolution and the roots of unity backend use no binomial coefficients, so warm makes no difference to them
+func runTrials(w io.Writer, backend string, n, m, trials int, warm bool) error {
+	name, compute, err := selectBackend(backend, n, m)
+	if err != nil {
+		return err
+	}
+
+	times := make([]time.Duration, trials)
+	for i := range times {
+		if !warm {
+			binomialCache.Clear()
+		}
+		start := time.Now()
+		compute(n, m)
+		times[i] = time.Since(start)
+	}
+	slices.Sort(times)
+
+	// with an even number of trials the median is halfway between the middle two
+	median := times[trials/2]
+	if trials%2 == 0 {
+		median = times[trials/2-1] + (times[trials/2]-times[trials/2-1])/2
+	}
+	fmt.Fprintf(w, "%d trials of the %s backend: min %v, median %v, max %v\n", trials, name,
+		times[0].Round(time.Microsecond), median.Round(time.Microsecond), times[trials-1].Round(time.Microsecond))
+	return nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+)
+
+// writeChart draws the distribution as a horizontal bar chart. The counts are all very close to the mean
+// 2^n/m, so each bar shows the count's deviation from the mean instead: left of the axis if below it, right of
+// it if above, with the largest deviation 'width' characters long. The relative deviation is printed alongside,
+// computed to prec bits
+func writeChart(w io.Writer, r *Result, width int, prec uint) {
+	total := new(big.Int)
+	for _, t := range r.Totals {
+		total.Add(total, t)
+	}
+
+	// m * count - total is m times the deviation from the mean, which keeps everything an integer
+	m := big.NewInt(int64(r.M))
+	devs := make([]*big.Int, r.M)
+	largest := new(big.Int)
+	for i, t := range r.Totals {
+		devs[i] = new(big.Int).Mul(t, m)
+		devs[i].Sub(devs[i], total)
+		if a := new(big.Int).Abs(devs[i]); a.Cmp(largest) > 0 {
+			largest = a
+		}
+	}
+
+	labelWidth := len(fmt.Sprint(r.M - 1))
+	for i, d := range devs {
+		length := 0
+		if largest.Sign() != 0 {
+			// round width * |d| / largest to the nearest integer
+			scaled := new(big.Int).Mul(new(big.Int).Abs(d), big.NewInt(int64(2*width)))
+			scaled.Add(scaled, largest)
+			length = int(scaled.Quo(scaled, new(big.Int).Lsh(largest, 1)).Int64())
+		}
+		left, right := strings.Repeat(" ", width), ""
+		if d.Sign() < 0 {
+			left = strings.Repeat(" ", width-length) + strings.Repeat("#", length)
+		} else {
+			right = strings.Repeat("#", length)
+		}
+		relative := new(big.Float).SetPrec(prec).SetInt(d)
+		relative.Quo(relative, new(big.Float).SetPrec(prec).SetInt(total))
+		fmt.Fprintf(w, "%*d %+10.3e %s|%s\n", labelWidth, i, relative, left, right)
+	}
+}
+
+// runChart implements the chart subcommand, with the relative deviations computed to floatPrec bits
+func runChart(w io.Writer, args []string, floatPrec uint) error {
+	fs := flag.NewFlagSet("chart", flag.ExitOnError)
+	n := fs.Int("n", 20, "count subsets of {1,...,n}")
+	m := fs.Int("m", columns, "modulus")
+	width := fs.Int("width", 30, "length of the longest bar")
+	fs.Parse(args)
+
+	for _, err := range []error{validateN(*n), validateModulus(*m)} {
+		if err != nil {
+			return err
+		}
+	}
+	if *width < 1 {
+		return fmt.Errorf("bad -width %d, must be at least 1", *width)
+	}
+	fmt.Fprintf(w, "Deviation from the mean 2^%d/%d of the number of subsets with each residue:\n", *n, *m)
+	writeChart(w, ResidueDistribution(*n, *m), *width, floatPrec)
+	return nil
+}
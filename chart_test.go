@@ -0,0 +1,33 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestChartGolden(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		args []string
+	}{
+		// {1,...,10} modulo 7 deviates visibly in both directions
+		{"n10-m7", []string{"-n", "10", "-m", "7", "-width", "20"}},
+		{"n20-m12", []string{"-n", "20", "-m", "12", "-width", "30"}},
+		// every count is the mean, so there are no bars
+		{"n12-m4", []string{"-n", "12", "-m", "4", "-width", "10"}},
+	} {
+		var buf bytes.Buffer
+		if err := runChart(&buf, tc.args, 64); err != nil {
+			t.Fatal(err)
+		}
+		checkGolden(t, "chart-"+tc.name+".golden", buf.Bytes())
+	}
+}
+
+func TestChartRejectsBadFlags(t *testing.T) {
+	for _, args := range [][]string{{"-n", "-1"}, {"-m", "0"}, {"-width", "0"}} {
+		if err := runChart(new(bytes.Buffer), args, 64); err == nil {
+			t.Errorf("chart %v did not fail", args)
+		}
+	}
+}
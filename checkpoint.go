@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+)
+
+// checkpoint records the progress of a recursion: the totals of the first Done branches of
+// expandBranches, which are always completed in order
+type checkpoint struct {
+	Done    int
+	Partial *Result
+}
+
+// saveCheckpoint writes c to path. It is written to a temporary file first and renamed,
+// so an interruption while saving never leaves a truncated checkpoint behind
+func saveCheckpoint(path string, c *checkpoint) error {
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if err := gob.NewEncoder(f).Encode(c); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// loadCheckpoint reads a checkpoint written by saveCheckpoint. A missing file is not an error,
+// it just means there is nothing to resume, and nil is returned
+func loadCheckpoint(path string) (*checkpoint, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	c := &checkpoint{}
+	if err := gob.NewDecoder(f).Decode(c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// recursionCheckpointed is recursionContext that saves its progress to the file at path after each
+// branch, and resumes from that file if it already holds a checkpoint for the same n and m.
+// The branches run one at a time so they complete in order, and the file is removed once the
+// result is complete. If ctx is done, the totals of the completed branches are returned with the
+// context's error and the checkpoint is kept for the next run. If onBranch is not nil it is called
+// after each branch is saved, counting the branches done by earlier runs too
+//
+// Progress is only saved between branches. They are the branches of the first branchDepth() = 2 levels, and
+// the first row only has a nonzero entry for residue 0, so there are at most m of them. An interruption during
+// the first branch saves nothing, and one during a later branch loses the work done on that branch
+func recursionCheckpointed(ctx context.Context, n, m int, path string, onBranch func(done, branches int, totals []*big.Int)) (*Result, error) {
+	r := &recurse{n: n, m: m, done: ctx.Done()}
+	r.initialize()
+
+	r.computeColumnModuloTotals()
+
+	depth := r.branchDepth()
+	branches := r.expandBranches(depth)
+
+	start := 0
+	c, err := loadCheckpoint(path)
+	if err != nil {
+		return nil, err
+	}
+	if c != nil {
+		if c.Partial.N != n || c.Partial.M != m || c.Done > len(branches) {
+			return nil, fmt.Errorf("checkpoint %s is for n = %d and m = %d, not n = %d and m = %d",
+				path, c.Partial.N, c.Partial.M, n, m)
+		}
+		start = c.Done
+		r.totals = c.Partial.Totals
+	}
+
+	for i := start; i < len(branches); i++ {
+		totals, stopped := r.runBranch(branches[i], depth)
+		if stopped {
+			return r.result(), ctx.Err()
+		}
+		for j, t := range totals {
+			r.totals[j].Add(r.totals[j], t)
+		}
+		if err := saveCheckpoint(path, &checkpoint{Done: i + 1, Partial: r.result()}); err != nil {
+			return nil, err
+		}
+		if onBranch != nil {
+			onBranch(i+1, len(branches), r.snapshot())
+		}
+	}
+
+	r.checkTotals()
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	return r.result(), nil
+}
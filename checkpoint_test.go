@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRecursionCheckpointedResumes(t *testing.T) {
+	const n, m = 5000, 7
+	want := ResidueDistribution(n, m).Totals
+	path := filepath.Join(t.TempDir(), "run.checkpoint")
+
+	// a deadline that has already passed interrupts the first branch, which leaves nothing to save
+	ctx, cancel := context.WithTimeout(context.Background(), 0)
+	res, err := recursionCheckpointed(ctx, n, m, path, nil)
+	cancel()
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %v, want context.DeadlineExceeded", err)
+	}
+	for r, c := range res.Totals {
+		if c.Sign() != 0 {
+			t.Errorf("residue %d has %v before any branch completed", r, c)
+		}
+	}
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("checkpoint saved with no branch completed: %v", err)
+	}
+
+	// interrupt between the second and third branches, after the second has been saved
+	var saved []*big.Int
+	ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+	_, err = recursionCheckpointed(ctx, n, m, path, func(done, branches int, totals []*big.Int) {
+		if done == 2 {
+			saved = totals
+			cancel()
+		}
+	})
+	cancel()
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("got %v, want context.Canceled", err)
+	}
+	c, err := loadCheckpoint(path)
+	if err != nil || c == nil {
+		t.Fatalf("loading the checkpoint gave %v, %v", c, err)
+	}
+	if c.Done != 2 || !sameCounts(c.Partial.Totals, saved) {
+		t.Errorf("checkpoint has %d branches with %v, want 2 with %v", c.Done, c.Partial.Totals, saved)
+	}
+
+	// a checkpoint for another problem is not resumed
+	if _, err := recursionCheckpointed(context.Background(), n+1, m, path, nil); err == nil {
+		t.Error("resumed a checkpoint for n = 5000 with n = 5001")
+	}
+
+	// resuming carries on from the third branch and gives the whole distribution
+	first := 0
+	ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	res, err = recursionCheckpointed(ctx, n, m, path, func(done, branches int, totals []*big.Int) {
+		if first == 0 {
+			first = done
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first != 3 {
+		t.Errorf("resumed at branch %d, want 3", first)
+	}
+	if !sameCounts(res.Totals, want) {
+		t.Errorf("resumed run gave %v, want %v", res.Totals, want)
+	}
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("checkpoint not removed after completing: %v", err)
+	}
+}
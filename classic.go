@@ -0,0 +1,21 @@
+package main
+
+import (
+	"fmt"
+	"io"
+)
+
+// classicExpected is the answer to the original question: the number of subsets of {1,...,2000}
+// whose sum is divisible by 5
+const classicExpected = "22962613905485090484656664023553639680446354041773904009552854736515325227847406277133189726330125398368919292779749255468942379217261106628518627123333063707825997829062456000137755829648008974285785398012697248956323092729277672789463405208093270794180999311632479761788925921124662329907232844394066536268833781796891701120475896961582811780186955300085800543341325166104401626447256258352253576663441319799079283625404355971680808431970636650308177886780418384110991556717934409897816293912852988275811422719154702569434391547265221166310540389294622648560061463880851178273858239474974548427800576"
+
+// runClassic implements the classic subcommand, which answers the original question
+// and checks the answer against classicExpected
+func runClassic(w io.Writer) {
+	r := recursion(rows*columns, columns)
+	if r.Totals[0].String() != classicExpected {
+		panic(fmt.Errorf("%w: classic answer %v does not match the expected value", ErrInternalCheckFailed, r.Totals[0]))
+	}
+	fmt.Fprintln(w, "Number of subsets of {1,...,2000} whose sum is divisible by 5:")
+	printResult(w, r, FormatText)
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+// classicAnswer is the number of subsets of {1,...,2000} whose sum is divisible by 5, kept separately
+// from classicExpected so that a change to either one is caught
+const classicAnswer = "22962613905485090484656664023553639680446354041773904009552854736515325227847406277133189726330125398368919292779749255468942379217261106628518627123333063707825997829062456000137755829648008974285785398012697248956323092729277672789463405208093270794180999311632479761788925921124662329907232844394066536268833781796891701120475896961582811780186955300085800543341325166104401626447256258352253576663441319799079283625404355971680808431970636650308177886780418384110991556717934409897816293912852988275811422719154702569434391547265221166310540389294622648560061463880851178273858239474974548427800576"
+
+func TestClassic(t *testing.T) {
+	if classicExpected != classicAnswer {
+		t.Fatal("classicExpected was changed")
+	}
+	for name, compute := range backends {
+		if got := compute(2000, 5).Totals[0].String(); got != classicAnswer {
+			t.Errorf("the %s backend gives %s", name, got)
+		}
+	}
+
+	var out bytes.Buffer
+	runClassic(&out)
+	if want := "Number of subsets of {1,...,2000} whose sum is divisible by 5:\n" + classicAnswer + "\n"; out.String() != want {
+		t.Errorf("classic printed %q, want %q", out.String(), want)
+	}
+}
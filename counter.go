@@ -0,0 +1,200 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+)
+
+// Counter keeps the distribution of subset sums modulo m of a universe that grows one element at a time.
+// Adding an element multiplies the distribution by (1 + x^element) in Z[x]/(x^m - 1), exactly as simple() does
+type Counter struct {
+	m      int
+	totals []*big.Int
+	next   []*big.Int
+}
+
+// NewCounter returns a Counter for modulus m holding the empty universe, whose only subset is the empty set
+func NewCounter(m int) *Counter {
+	checkModulus(m)
+	c := &Counter{m: m, totals: make([]*big.Int, m), next: make([]*big.Int, m)}
+	for i := 0; i < m; i++ {
+		c.totals[i] = new(big.Int)
+		c.next[i] = new(big.Int)
+	}
+	c.totals[0].SetInt64(1)
+	return c
+}
+
+// Add adds an element to the universe
+func (c *Counter) Add(value int) {
+	shift := value % c.m
+	if shift < 0 {
+		shift += c.m
+	}
+	// the subsets without the new element keep their sums, the ones with it are shifted by 'shift'
+	for col := 0; col < c.m; col++ {
+		// k = (col + shift) % m, without a division in the inner loop, as in simple()
+		k := col + shift
+		if k >= c.m {
+			k -= c.m
+		}
+		c.next[k].Add(c.totals[k], c.totals[col])
+	}
+	c.totals, c.next = c.next, c.totals
+}
+
+// Count returns the number of subsets of the current universe whose sum is r modulo m
+func (c *Counter) Count(r int) *big.Int {
+	checkResidue(r, c.m)
+	return new(big.Int).Set(c.totals[r])
+}
+
+// Distribution returns a copy of the current distribution
+func (c *Counter) Distribution() []*big.Int {
+	dist := make([]*big.Int, c.m)
+	for i, t := range c.totals {
+		dist[i] = new(big.Int).Set(t)
+	}
+	return dist
+}
+
+// CountStreaming returns the number of subsets of {1,...,n} whose sum is congruent to target modulo m. The elements
+// are fed to a Counter one at a time and never stored, so it uses O(m) space for any n and O(n*m) additions
+func CountStreaming(n, m, target int) *big.Int {
+	checkN(n)
+	c := NewCounter(m)
+	checkResidue(target, m)
+	for i := 1; i <= n; i++ {
+		c.Add(i)
+	}
+	return c.Count(target)
+}
+
+// DivisibleSweep returns, for each n in ns, the number of subsets of {1,...,n} whose sum is divisible by m.
+// The universe is only grown once, up to the largest n, reading off the count at each requested n on the way
+func DivisibleSweep(m int, ns []int) map[int]*big.Int {
+	sorted := append([]int(nil), ns...)
+	sort.Ints(sorted)
+
+	results := make(map[int]*big.Int, len(ns))
+	c := NewCounter(m)
+	size := 0
+	for _, n := range sorted {
+		checkN(n)
+		for size < n {
+			size++
+			c.Add(size)
+		}
+		results[n] = c.Count(0)
+	}
+	return results
+}
+
+// DistributionBuilder builds a distribution of subset sums modulo m one residue class at a time,
+// which is the granularity of the binomial method. Adding a class convolves the current distribution
+// with the class's row of the sums matrix
+type DistributionBuilder struct {
+	m    int
+	dist []*big.Int
+}
+
+// NewDistributionBuilder returns a builder for modulus m holding the empty universe
+func NewDistributionBuilder(m int) *DistributionBuilder {
+	checkModulus(m)
+	dist := make([]*big.Int, m)
+	for r := range dist {
+		dist[r] = new(big.Int)
+	}
+	dist[0].SetInt64(1)
+	return &DistributionBuilder{m: m, dist: dist}
+}
+
+// AddResidueClass adds count elements that are all congruent to residue modulo m
+func (b *DistributionBuilder) AddResidueClass(residue, count int) {
+	checkResidue(residue, b.m)
+	checkN(count)
+	b.dist = convolve(b.dist, columnRow(count, residue, b.m))
+}
+
+// Distribution returns a copy of the current distribution
+func (b *DistributionBuilder) Distribution() []*big.Int {
+	dist := make([]*big.Int, b.m)
+	for r, t := range b.dist {
+		dist[r] = new(big.Int).Set(t)
+	}
+	return dist
+}
+
+// DeltaAddElement returns the number of subsets with sum divisible by m after adding an element with the given
+// value to a universe whose distribution is dist. The new subsets are the old ones with the element added,
+// so the count grows by the old count of residue -value, which is the returned new count minus dist[0]
+func DeltaAddElement(dist []*big.Int, value, m int) *big.Int {
+	checkModulus(m)
+	if len(dist) != m {
+		panic(fmt.Errorf("%w: distribution of length %d for modulus %d", ErrResidueCountLengthMismatch, len(dist), m))
+	}
+	shifted := ((-value)%m + m) % m
+	return new(big.Int).Add(dist[0], dist[shifted])
+}
+
+// RemoveElements returns the distribution of a universe whose distribution modulo m is dist after taking out
+// the elements elems, undoing Counter.Add. Removing an element with residue s divides by (1 + x^s): the new
+// distribution d satisfies d[r] + d[r-s] = dist[r], which links the residues in cycles r, r+s, r+2s, ... of
+// length q = m/gcd(s, m). Going once around a cycle, the alternating sum of dist is 2d[r] when q is odd, so each
+// d[r] is found by halving it. When q is even the alternating sum cancels d[r] and the answer is not unique,
+// so such elements can't be removed. An error wrapping ErrElementNotRemovable is also returned if a halving
+// is not exact or a count would become negative, which means the element was not in the universe
+func RemoveElements(dist []*big.Int, elems []int, m int) ([]*big.Int, error) {
+	if err := validateModulus(m); err != nil {
+		return nil, err
+	}
+	if len(dist) != m {
+		return nil, fmt.Errorf("%w: distribution of length %d for modulus %d", ErrResidueCountLengthMismatch, len(dist), m)
+	}
+	cur := make([]*big.Int, m)
+	for r, t := range dist {
+		cur[r] = new(big.Int).Set(t)
+	}
+
+	alt := new(big.Int)
+	for _, e := range elems {
+		s := e % m
+		if s < 0 {
+			s += m
+		}
+		q := m / gcd(s, m)
+		if q%2 == 0 {
+			return nil, fmt.Errorf("%w: %d has even order %d modulo %d, so removing it is ambiguous", ErrElementNotRemovable, e, q, m)
+		}
+
+		next := make([]*big.Int, m)
+		for start := 0; start < m; start++ {
+			if next[start] != nil {
+				continue
+			}
+			// d[start] = (dist[start] - dist[start-s] + dist[start-2s] - ...) / 2 over the cycle
+			alt.SetInt64(0)
+			for j, r := 0, start; j < q; j, r = j+1, (r-s+m)%m {
+				if j%2 == 0 {
+					alt.Add(alt, cur[r])
+				} else {
+					alt.Sub(alt, cur[r])
+				}
+			}
+			if alt.Bit(0) != 0 {
+				return nil, fmt.Errorf("%w: %d is not in the universe", ErrElementNotRemovable, e)
+			}
+			next[start] = new(big.Int).Rsh(alt, 1)
+			// then d[r+s] = dist[r+s] - d[r] around the rest of the cycle
+			for r := start; (r+s)%m != start; r = (r + s) % m {
+				next[(r+s)%m] = new(big.Int).Sub(cur[(r+s)%m], next[r])
+			}
+		}
+		if err := checkNonNegative(next); err != nil {
+			return nil, fmt.Errorf("%w: %d is not in the universe", ErrElementNotRemovable, e)
+		}
+		cur = next
+	}
+	return cur, nil
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"math/rand"
+	"testing"
+)
+
+func TestDivisibleSweepMatchesIndependent(t *testing.T) {
+	for _, m := range []int{1, 5, 12} {
+		// unsorted, with a repeat and n = 0
+		ns := []int{200, 100, 0, 2000, 100, 1, 777}
+		got := DivisibleSweep(m, ns)
+		if len(got) != 6 {
+			t.Errorf("m = %d: %d results, want one for each of the 6 distinct n", m, len(got))
+		}
+		for _, n := range ns {
+			if want := ResidueDistribution(n, m).Totals[0]; got[n].Cmp(want) != 0 {
+				t.Errorf("m = %d, n = %d: sweep gave %v, want %v", m, n, got[n], want)
+			}
+		}
+	}
+}
+
+func TestCounterAddNegativeAndLarge(t *testing.T) {
+	// values are taken modulo m, so these add the same residues as 1 ... 7
+	c := NewCounter(7)
+	for _, v := range []int{1, -5, 17, 4 - 7*1000, 5, 6 + 7*3, -7} {
+		c.Add(v)
+	}
+	if got, want := c.Distribution(), ResidueDistribution(7, 7).Totals; !sameCounts(got, want) {
+		t.Errorf("distribution %v, want %v", got, want)
+	}
+}
+
+// BenchmarkLargeModulus runs the convolution for m = 100000 and n = 1000, where each step is a long vector. Both
+// the two vectors of simple and the Counter used by convolutionContext are updated in place, so the allocations
+// are the initial vectors and the growth of their entries, not one vector per element
+func BenchmarkLargeModulus(b *testing.B) {
+	const n, m = 1000, 100000
+	b.Run("simple", func(b *testing.B) {
+		defer recursionWorkers.Store(recursionWorkers.Load())
+		recursionWorkers.Store(1)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			simple(n, m)
+		}
+	})
+	b.Run("counter", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := convolutionContext(context.Background(), n, m); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestDistributionBuilderMatchesOneShot(t *testing.T) {
+	rng := rand.New(rand.NewSource(152))
+	for _, tc := range []struct{ n, m int }{{2000, 5}, {100, 7}, {0, 3}, {45, 1}} {
+		counts := RangeResidueCounts(tc.n, tc.m)
+		want := ResidueDistribution(tc.n, tc.m).Totals
+
+		// the classes can be added in any order, and a class can be split into several parts
+		for _, order := range [][]int{rng.Perm(tc.m), rng.Perm(tc.m)} {
+			b := NewDistributionBuilder(tc.m)
+			for _, residue := range order {
+				part := counts[residue] / 3
+				b.AddResidueClass(residue, part)
+				b.AddResidueClass(residue, counts[residue]-part)
+			}
+			if got := b.Distribution(); !sameCounts(got, want) {
+				t.Errorf("n = %d, m = %d, classes in order %v: %v, want %v", tc.n, tc.m, order, got, want)
+			}
+		}
+	}
+
+	b := NewDistributionBuilder(4)
+	b.Distribution()[0].SetInt64(7)
+	if got := b.Distribution()[0]; got.Int64() != 1 {
+		t.Errorf("changing a returned distribution changed the empty universe's count to %v", got)
+	}
+}
+
+func TestDeltaAddElementMatchesCounter(t *testing.T) {
+	for _, m := range []int{1, 5, 7, 12} {
+		c := NewCounter(m)
+		for i := 1; i <= 30; i++ {
+			c.Add(i)
+		}
+		for _, value := range []int{-13, -1, 0, 1, 5, 31, 1000} {
+			after := NewCounter(m)
+			for i := 1; i <= 30; i++ {
+				after.Add(i)
+			}
+			after.Add(value)
+			if got, want := DeltaAddElement(c.Distribution(), value, m), after.Distribution()[0]; got.Cmp(want) != 0 {
+				t.Errorf("m = %d: adding %d gives %v subsets with sum divisible by m, want %v", m, value, got, want)
+			}
+		}
+	}
+
+	err := panicError(func() { DeltaAddElement(ResidueDistribution(10, 4).Totals, 3, 5) })
+	if !errors.Is(err, ErrResidueCountLengthMismatch) {
+		t.Errorf("distribution of the wrong length gave %v, want ErrResidueCountLengthMismatch", err)
+	}
+}
+
+func TestRemoveElementsUndoesAdd(t *testing.T) {
+	for _, tc := range []struct {
+		n, m  int
+		elems []int
+	}{
+		{2000, 5, []int{3, 1000, -7, 5}},
+		{100, 7, []int{1, 1, 1, 2}},
+		{30, 9, []int{2, 4, 6}},
+		{40, 6, []int{2, 4, 6, 12}},
+		{0, 1, []int{5}},
+		{20, 15, nil},
+	} {
+		base := ResidueDistribution(tc.n, tc.m).Totals
+		c := NewCounter(tc.m)
+		for i := 1; i <= tc.n; i++ {
+			c.Add(i)
+		}
+		for _, e := range tc.elems {
+			c.Add(e)
+		}
+		got, err := RemoveElements(c.Distribution(), tc.elems, tc.m)
+		if err != nil {
+			t.Errorf("n = %d, m = %d, removing %v: %v", tc.n, tc.m, tc.elems, err)
+			continue
+		}
+		if !sameCounts(got, base) {
+			t.Errorf("n = %d, m = %d: removing %v after adding it gave %v, want %v", tc.n, tc.m, tc.elems, got, base)
+		}
+	}
+
+	// removing the elements of {1,...,n} one by one leaves the empty universe
+	got, err := RemoveElements(ResidueDistribution(25, 5).Totals, oneTo(25), 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []*big.Int{big.NewInt(1), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0)}; !sameCounts(got, want) {
+		t.Errorf("removing {1,...,25} gave %v, want %v", got, want)
+	}
+
+	for _, tc := range []struct {
+		dist  []*big.Int
+		elems []int
+		m     int
+		want  error
+	}{
+		// nothing to remove from the empty universe
+		{NewCounter(5).Distribution(), []int{1}, 5, ErrElementNotRemovable},
+		// 3 has order 2 modulo 6
+		{ResidueDistribution(12, 6).Totals, []int{3}, 6, ErrElementNotRemovable},
+		{ResidueDistribution(12, 6).Totals, []int{1}, 5, ErrResidueCountLengthMismatch},
+		{nil, nil, 0, ErrInvalidModulus},
+	} {
+		if _, err := RemoveElements(tc.dist, tc.elems, tc.m); !errors.Is(err, tc.want) {
+			t.Errorf("removing %v modulo %d from %v gave %v, want %v", tc.elems, tc.m, tc.dist, err, tc.want)
+		}
+	}
+}
+
+func TestCountStreamingMatchesRecursion(t *testing.T) {
+	for _, tc := range []struct{ n, m int }{{2000, 5}, {300, 7}, {40, 8}, {0, 3}, {17, 1}} {
+		want := recursion(tc.n, tc.m).Totals
+		for target := 0; target < tc.m; target++ {
+			if got := CountStreaming(tc.n, tc.m, target); got.Cmp(want[target]) != 0 {
+				t.Errorf("CountStreaming(%d, %d, %d) = %v, want %v", tc.n, tc.m, target, got, want[target])
+			}
+		}
+	}
+	if err := panicError(func() { CountStreaming(10, 5, 5) }); !errors.Is(err, ErrResidueOutOfRange) {
+		t.Errorf("target 5 modulo 5 gave %v, want ErrResidueOutOfRange", err)
+	}
+}
+
+// BenchmarkCountStreaming shows the memory of the streaming count for growing n. No element is stored: what is
+// live is the two length m vectors, whose counts are about n bits long. The allocations reported are the counts
+// being reallocated as they grow, about one for every few words they gain
+func BenchmarkCountStreaming(b *testing.B) {
+	for _, n := range []int{1000, 10000, 100000} {
+		b.Run(fmt.Sprintf("n=%d", n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				CountStreaming(n, 5, 0)
+			}
+		})
+	}
+}
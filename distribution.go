@@ -0,0 +1,415 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"sync"
+	"sync/atomic"
+)
+
+// Result holds the distribution of the sums of all 2^N subsets of {1,...,N} modulo M.
+// Totals[r] is the number of subsets whose sum is r modulo M, so Totals[0] answers
+// the original question
+type Result struct {
+	N      int
+	M      int
+	Totals []*big.Int
+}
+
+// Clone returns a deep copy of r, so the copy's counts can be modified without affecting r
+func (r *Result) Clone() *Result {
+	c := &Result{N: r.N, M: r.M, Totals: make([]*big.Int, len(r.Totals))}
+	for i, t := range r.Totals {
+		c.Totals[i] = new(big.Int).Set(t)
+	}
+	return c
+}
+
+// WithoutEmpty returns a copy of r that leaves out the empty subset. Its sum is 0, so only Totals[0] goes down
+// by one, and the counts add up to 2^N - 1, which is checked
+func (r *Result) WithoutEmpty() *Result {
+	c := r.Clone()
+	c.Totals[0].Sub(c.Totals[0], big.NewInt(1))
+
+	// adding the empty subset back must give 2^N
+	sum := big.NewInt(1)
+	for _, t := range c.Totals {
+		sum.Add(sum, t)
+	}
+	if !isPowerOfTwo(sum, r.N) {
+		panic(fmt.Errorf("%w: the counts without the empty subset do not add up to 2^%d - 1", ErrInternalCheckFailed, r.N))
+	}
+	if err := checkNonNegative(c.Totals); err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// ResidueDistribution computes the distribution of subset sums of {1,...,n} modulo m
+// using the simple iterative method
+func ResidueDistribution(n, m int) *Result {
+	checkModulus(m)
+	checkN(n)
+	return simple(n, m)
+}
+
+// DistributionsEqual reports whether two results have identical distributions, entry by entry.
+// N is not compared, so two different universes with the same distribution are considered equal
+func DistributionsEqual(a, b Result) bool {
+	if a.M != b.M || len(a.Totals) != len(b.Totals) {
+		return false
+	}
+	for i, t := range a.Totals {
+		if t.Cmp(b.Totals[i]) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// The full sum distribution has n(n+1)/2 + 1 entries, each of which can be up to n bits long,
+// so memory and time grow as n^3. Beyond this limit it is no longer practical
+const maxFullSumN = 1000
+
+// FullSumDistribution returns the coefficients of the polynomial (1 + x)(1 + x^2)...(1 + x^n).
+// Entry s is the number of subsets of {1,...,n} whose sum is exactly s.
+// n must not exceed maxFullSumN
+func FullSumDistribution(n int) []*big.Int {
+	checkN(n)
+	if n > maxFullSumN {
+		panic(fmt.Errorf("%w: %d exceeds the full sum distribution limit of %d", ErrNTooLarge, n, maxFullSumN))
+	}
+
+	maxSum := n * (n + 1) / 2
+	dist := make([]*big.Int, maxSum+1)
+	for s := range dist {
+		dist[s] = new(big.Int)
+	}
+	dist[0].SetInt64(1)
+
+	// multiply by (1 + x^i) in place, working downwards so each subset only uses i once
+	top := 0
+	for i := 1; i <= n; i++ {
+		top += i
+		for s := top; s >= i; s-- {
+			dist[s].Add(dist[s], dist[s-i])
+		}
+	}
+	return dist
+}
+
+// CountDivisibleUpTo counts the subsets of {1,...,n} whose sum is divisible by m and at most t.
+// This needs the actual sums, not just their residues, so it is subject to the maxFullSumN limit
+func CountDivisibleUpTo(n, m, t int) *big.Int {
+	checkModulus(m)
+	dist := FullSumDistribution(n)
+	count := new(big.Int)
+	for s := 0; s <= t && s < len(dist); s += m {
+		count.Add(count, dist[s])
+	}
+	return count
+}
+
+// binomials returns the binomial coefficients C(count, 0) ... C(count, count),
+// computed in sequence the same way as binomial.populate()
+func binomials(count int) []*big.Int {
+	vals := make([]*big.Int, count+1)
+	accum := big.NewInt(1)
+	for k := range vals {
+		vals[k] = new(big.Int).Set(accum)
+		// C(count, k+1) = C(count, k) * (count - k) / (k + 1)
+		accum.Mul(accum, big.NewInt(int64(count-k)))
+		accum.Div(accum, big.NewInt(int64(k+1)))
+	}
+	return vals
+}
+
+// binomialCache memoizes binomials by count for Binomials, unless noBinomialCache is set
+var (
+	binomialCache   sync.Map
+	noBinomialCache atomic.Bool
+)
+
+// sharedBinomials returns the binomial coefficients C(count, 0) ... C(count, count) from the cache, computing them
+// if needed, or always computes them if noBinomialCache is set. The slice may be shared, so it must not be modified
+func sharedBinomials(count int) []*big.Int {
+	if noBinomialCache.Load() {
+		return binomials(count)
+	}
+	cached, ok := binomialCache.Load(count)
+	if !ok {
+		cached, _ = binomialCache.LoadOrStore(count, binomials(count))
+	}
+	return cached.([]*big.Int)
+}
+
+// Binomials returns the binomial coefficients C(count, 0) ... C(count, count). Results are cached, and every
+// caller gets its own copy, so the returned slice may be modified freely. It is safe for concurrent use
+func Binomials(count int) []*big.Int {
+	checkN(count)
+	vals := sharedBinomials(count)
+	clone := make([]*big.Int, len(vals))
+	for k, v := range vals {
+		clone[k] = new(big.Int).Set(v)
+	}
+	return clone
+}
+
+// convolve returns the cyclic convolution of a and b, which must have the same length m.
+// This is the product of the two polynomials in Z[x]/(x^m - 1)
+func convolve(a, b []*big.Int) []*big.Int {
+	out := make([]*big.Int, len(a))
+	for i := range out {
+		out[i] = new(big.Int)
+	}
+	cyclicConvolve(out, a, b)
+	return out
+}
+
+// cyclicConvolve sets dst to the cyclic convolution of a and b, reusing the big.Ints already in dst. All three
+// must have the same length m. Like the big.Int methods, dst may be a or b itself, in which case the result is
+// built in a scratch vector first; otherwise dst must not share any big.Int with a or b. Without aliasing a call
+// only allocates one temporary, plus whatever the big.Ints in dst need to grow. Zero entries of b are skipped
+// so sparse rows are cheap
+func cyclicConvolve(dst, a, b []*big.Int) {
+	m := len(a)
+	if len(b) != m || len(dst) != m {
+		panic(fmt.Errorf("%w: convolution of lengths %d and %d into %d", ErrResidueCountLengthMismatch, m, len(b), len(dst)))
+	}
+	if m == 0 {
+		return
+	}
+
+	out := dst
+	if &dst[0] == &a[0] || &dst[0] == &b[0] {
+		out = make([]*big.Int, m)
+		for i := range out {
+			out[i] = new(big.Int)
+		}
+	} else {
+		for _, d := range out {
+			d.SetInt64(0)
+		}
+	}
+
+	term := new(big.Int)
+	for j, bj := range b {
+		if bj.Sign() == 0 {
+			continue
+		}
+		for i, ai := range a {
+			k := i + j
+			if k >= m {
+				k -= m
+			}
+			out[k].Add(out[k], term.Mul(ai, bj))
+		}
+	}
+
+	if out[0] != dst[0] {
+		for i, o := range out {
+			dst[i].Set(o)
+		}
+	}
+}
+
+// DivisibleFraction returns the exact fraction of the subsets of {1,...,n} whose sum is divisible by m
+func DivisibleFraction(n, m int) *big.Rat {
+	r := ResidueDistribution(n, m)
+	return new(big.Rat).SetFrac(r.Totals[0], new(big.Int).Lsh(big.NewInt(1), uint(n)))
+}
+
+// DivisibleProbability returns the probability that a uniformly random subset of {1,...,n} has a sum
+// divisible by m, correctly rounded to prec bits. For large n this is extremely close to 1/m, so
+// a high precision is needed to see the deviation
+func DivisibleProbability(n, m int, prec uint) *big.Float {
+	return new(big.Float).SetPrec(prec).SetRat(DivisibleFraction(n, m))
+}
+
+// CombineDistributions returns the distribution of subset sums modulo m of the union of two disjoint universes,
+// given the distribution of each. A subset of the union is a subset of each universe, and their sums add,
+// so this is the cyclic convolution of a and b
+func CombineDistributions(a, b []*big.Int, m int) []*big.Int {
+	checkModulus(m)
+	if len(a) != m || len(b) != m {
+		panic(fmt.Errorf("%w: distributions of length %d and %d for modulus %d",
+			ErrResidueCountLengthMismatch, len(a), len(b), m))
+	}
+	return convolve(a, b)
+}
+
+// columnRow returns the distribution of the sums modulo m of all subsets of a column of
+// 'count' elements which are all congruent to 'residue' modulo m. This is one row of the sums matrix
+func columnRow(count, residue, m int) []*big.Int {
+	row := make([]*big.Int, m)
+	for i := range row {
+		row[i] = new(big.Int)
+	}
+	for k, b := range Binomials(count) {
+		contribution := contributionResidue(k, residue, m)
+		row[contribution].Add(row[contribution], b)
+	}
+	return row
+}
+
+// ContributionMatrix returns the m x m sums matrix the binomial method works from. Entry [v][c] is the number
+// of subsets of the elements of {1,...,n} congruent to v modulo m whose sum is c modulo m, so each row adds up
+// to 2^(number of elements congruent to v), and the cyclic convolution of all the rows is the distribution.
+// The matrix is the caller's own copy
+func ContributionMatrix(n, m int) [][]*big.Int {
+	r := &recurse{n: n, m: m}
+	r.initialize()
+	r.computeColumnModuloTotals()
+	return r.sums
+}
+
+// distributionFromCounts returns the distribution of subset sums modulo m = len(counts) of a
+// universe with counts[v] elements congruent to v. Each column's row is convolved into the result
+func distributionFromCounts(counts []int) []*big.Int {
+	m := len(counts)
+	dist := make([]*big.Int, m)
+	for r := range dist {
+		dist[r] = new(big.Int)
+	}
+	dist[0].SetInt64(1)
+	for v, count := range counts {
+		if count == 0 {
+			continue
+		}
+		dist = convolve(dist, columnRow(count, v, m))
+	}
+	if err := checkNonNegative(dist); err != nil {
+		panic(err)
+	}
+	return dist
+}
+
+// checkNonNegative returns an error if any entry of a distribution is negative.
+// Counts can never be negative, so this catches bugs in the computation
+func checkNonNegative(totals []*big.Int) error {
+	for r, t := range totals {
+		if t.Sign() < 0 {
+			return fmt.Errorf("%w: negative count %v for residue %d", ErrInternalCheckFailed, t, r)
+		}
+	}
+	return nil
+}
+
+// cyclicPolyProduct returns the coefficients of (1 + x^1)(1 + x^2)...(1 + x^n) in the ring Z[x]/(x^m - 1).
+// Since x^m = 1 in this ring, the coefficient of x^r is the number of subsets of {1,...,n} whose sum
+// is r modulo m, so this is the same vector as ResidueDistribution(n, m).Totals
+func cyclicPolyProduct(n, m int) []*big.Int {
+	c := NewCounter(m)
+	for i := 1; i <= n; i++ {
+		// multiplying by (1 + x^i) is the same as multiplying by (1 + x^(i mod m))
+		c.Add(i)
+	}
+	return c.Distribution()
+}
+
+// EvaluateSubsetPolynomial returns the value of (1 + x)(1 + x^2)...(1 + x^n) at the integer x,
+// which is the sum of x^sum over all subsets of {1,...,n}
+func EvaluateSubsetPolynomial(n int, x int64) *big.Int {
+	checkN(n)
+	value := big.NewInt(1)
+	power := big.NewInt(1)
+	factor := new(big.Int)
+	bx := big.NewInt(x)
+	for i := 1; i <= n; i++ {
+		power.Mul(power, bx)
+		value.Mul(value, factor.Add(power, big.NewInt(1)))
+	}
+	return value
+}
+
+// SignedSubsetCount returns the number of subsets of {1,...,n} with an even sum minus the number with an odd sum,
+// which is the subset polynomial evaluated at -1. The factor (1 + (-1)^1) is zero, so this is 0 for every n >= 1
+func SignedSubsetCount(n int) *big.Int {
+	return EvaluateSubsetPolynomial(n, -1)
+}
+
+// QSubsetDistribution returns the coefficients of the q-series (1 + q)(1 + q^2)...(1 + q^n), where each subset
+// of {1,...,n} is weighted by q^(sum of its elements). This is the full sum distribution viewed as a q-analog of
+// (1 + 1)^n = 2^n. Grouping the subsets by size k gives the q-binomial theorem
+//
+//	(1 + q)(1 + q^2)...(1 + q^n) = sum over k of q^(k(k+1)/2) [n choose k]_q
+//
+// where [n choose k]_q is the Gaussian binomial coefficient, and setting q = 1 gives back the binomial coefficients.
+// Taking complements maps a sum s to n(n+1)/2 - s, so the coefficients are symmetric
+func QSubsetDistribution(n int) []*big.Int {
+	coeffs := FullSumDistribution(n)
+
+	// Check the symmetry
+	for s, top := 0, len(coeffs)-1; s < top; s, top = s+1, top-1 {
+		if coeffs[s].Cmp(coeffs[top]) != 0 {
+			panic(fmt.Errorf("%w: q-series coefficients are not symmetric at %d", ErrInternalCheckFailed, s))
+		}
+	}
+	return coeffs
+}
+
+// ModuliAboveFraction returns, in increasing order, the moduli m in [2, k] for which the fraction
+// of subsets of {1,...,n} with sum divisible by m is strictly greater than threshold
+func ModuliAboveFraction(n, k int, threshold *big.Rat) []int {
+	var moduli []int
+	for m := 2; m <= k; m++ {
+		if DivisibleFraction(n, m).Cmp(threshold) > 0 {
+			moduli = append(moduli, m)
+		}
+	}
+	return moduli
+}
+
+// CountMiddleResidue returns the number of subsets of {1,...,n} whose sum is congruent to m/2
+// (rounded down) modulo m, the middle residue of the distribution
+func CountMiddleResidue(n, m int) *big.Int {
+	return ResidueDistribution(n, m).Totals[m/2]
+}
+
+// CountWithResidue returns the number of subsets of {1,...,n} whose sum is r modulo m. Every backend computes
+// the whole distribution anyway, so to count several residues of the same problem use AllTargets once instead
+func CountWithResidue(n, m, r int) *big.Int {
+	checkModulus(m)
+	checkResidue(r, m)
+	return AllTargets(n, m)[r]
+}
+
+// AllTargets returns the number of subsets of {1,...,n} with each sum modulo m, indexed by residue, using the
+// backend chooseBackend picks. Both the recursion and the convolution find every residue in the same pass,
+// so this costs the same as counting a single residue
+func AllTargets(n, m int) []*big.Int {
+	checkModulus(m)
+	checkN(n)
+	return chooseBackend(n, m)(n, m).Totals
+}
+
+// IsDivisibleCountAtLeast reports whether at least t subsets of {1,...,n} have a sum divisible by m. There is no
+// shortcut, the count is computed in full, but comparing it is much cheaper than formatting it to compare
+func IsDivisibleCountAtLeast(n, m int, t *big.Int) bool {
+	return CountWithResidue(n, m, 0).Cmp(t) >= 0
+}
+
+// ResidueDifferences returns d[r] = totals[r+1] - totals[r] for the distribution of subset sums of {1,...,n}
+// modulo m, with totals[m] taken as totals[0]. The counts are all close to 2^n/m, so the differences show the
+// fine structure, and going once around the cycle they add up to 0
+func ResidueDifferences(n, m int) []*big.Int {
+	totals := ResidueDistribution(n, m).Totals
+	diffs := make([]*big.Int, m)
+	for r := range diffs {
+		diffs[r] = new(big.Int).Sub(totals[(r+1)%m], totals[r])
+	}
+	return diffs
+}
+
+// RangeNonzero calls f for each residue r with a nonzero count c in dist, in increasing order of r,
+// stopping early if f returns false
+func RangeNonzero(dist []*big.Int, f func(r int, c *big.Int) bool) {
+	for r, c := range dist {
+		if c.Sign() == 0 {
+			continue
+		}
+		if !f(r, c) {
+			return
+		}
+	}
+}
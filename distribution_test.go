@@ -0,0 +1,670 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"math/rand"
+	"sync"
+	"testing"
+)
+
+// oneTo returns the elements 1 ... n
+func oneTo(n int) []int {
+	elems := make([]int, n)
+	for i := range elems {
+		elems[i] = i + 1
+	}
+	return elems
+}
+
+// forEachSubset calls f with each of the 2^len(elems) subsets of elems. The slice passed to f is reused
+func forEachSubset(elems []int, f func(subset []int)) {
+	subset := make([]int, 0, len(elems))
+	for mask := 0; mask < 1<<len(elems); mask++ {
+		subset = subset[:0]
+		for i, e := range elems {
+			if mask>>i&1 == 1 {
+				subset = append(subset, e)
+			}
+		}
+		f(subset)
+	}
+}
+
+// sumOf returns the sum of elems
+func sumOf(elems []int) int {
+	s := 0
+	for _, e := range elems {
+		s += e
+	}
+	return s
+}
+
+// bruteForceDistribution counts the subsets of elems by their sum modulo m, listing every one of them
+func bruteForceDistribution(elems []int, m int) []*big.Int {
+	dist := make([]*big.Int, m)
+	for r := range dist {
+		dist[r] = new(big.Int)
+	}
+	forEachSubset(elems, func(subset []int) {
+		r := (sumOf(subset)%m + m) % m
+		dist[r].Add(dist[r], big.NewInt(1))
+	})
+	return dist
+}
+
+// panicError runs f and returns the error it panicked with, or nil if it returned normally
+func panicError(f func()) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			var ok bool
+			if err, ok = p.(error); !ok {
+				err = fmt.Errorf("panic: %v", p)
+			}
+		}
+	}()
+	f()
+	return nil
+}
+
+// sameCounts reports whether a and b hold the same counts
+func sameCounts(a, b []*big.Int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Cmp(b[i]) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func TestDistributionsEqualUnitScaling(t *testing.T) {
+	// when m divides n every residue appears n/m times in {1,...,n}, and so it does after multiplying each
+	// element by a unit u, so the scaled universe has the same distribution
+	for _, tc := range []struct{ n, m, u int }{{10, 5, 2}, {12, 4, 3}, {14, 7, 3}, {18, 9, 4}} {
+		c := NewCounter(tc.m)
+		for i := 1; i <= tc.n; i++ {
+			c.Add(i * tc.u)
+		}
+		scaled := Result{N: tc.n, M: tc.m, Totals: c.Distribution()}
+		if !DistributionsEqual(*ResidueDistribution(tc.n, tc.m), scaled) {
+			t.Errorf("n = %d, m = %d: scaling by %d changed the distribution", tc.n, tc.m, tc.u)
+		}
+	}
+}
+
+func TestDistributionsEqualDiffers(t *testing.T) {
+	a := ResidueDistribution(7, 5)
+	if DistributionsEqual(*a, *ResidueDistribution(8, 5)) {
+		t.Error("n = 7 and n = 8 modulo 5 compared equal")
+	}
+	if DistributionsEqual(*ResidueDistribution(4, 2), *ResidueDistribution(4, 4)) {
+		t.Error("different moduli compared equal")
+	}
+	if !DistributionsEqual(*a, *a.Clone()) {
+		t.Error("a result is not equal to its clone")
+	}
+}
+
+func TestCountDivisibleUpToBruteForce(t *testing.T) {
+	for n := 0; n <= 10; n++ {
+		elems := oneTo(n)
+		for m := 1; m <= 6; m++ {
+			for _, limit := range []int{-1, 0, 3, 10, 27, 55, 100} {
+				want := int64(0)
+				forEachSubset(elems, func(subset []int) {
+					if s := sumOf(subset); s%m == 0 && s <= limit {
+						want++
+					}
+				})
+				if got := CountDivisibleUpTo(n, m, limit); got.Cmp(big.NewInt(want)) != 0 {
+					t.Errorf("CountDivisibleUpTo(%d, %d, %d) = %v, want %d", n, m, limit, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestDivisibleProbabilityMatchesFraction(t *testing.T) {
+	for _, tc := range []struct {
+		n, m int
+		prec uint
+	}{{0, 3, 53}, {10, 5, 64}, {2000, 5, 2000}, {100, 7, 300}, {57, 12, 24}} {
+		exact := DivisibleFraction(tc.n, tc.m)
+		got := DivisibleProbability(tc.n, tc.m, tc.prec)
+		if got.Prec() != tc.prec {
+			t.Errorf("n = %d, m = %d: precision %d, want %d", tc.n, tc.m, got.Prec(), tc.prec)
+		}
+		// correctly rounded means within half a unit in the last place of the exact fraction, 2^(exp - prec - 1)
+		value, _ := got.Rat(nil)
+		diff := new(big.Rat).Sub(exact, value)
+		halfULP := new(big.Rat).SetFrac(big.NewInt(1), new(big.Int).Lsh(big.NewInt(1), tc.prec-uint(got.MantExp(nil))+1))
+		if diff.Abs(diff).Cmp(halfULP) > 0 {
+			t.Errorf("n = %d, m = %d, prec %d: %v is %v away from the exact fraction", tc.n, tc.m, tc.prec, got, diff)
+		}
+	}
+}
+
+func TestCheckNonNegative(t *testing.T) {
+	// every backend passes its own check, and the result passes it again
+	for name, compute := range backends {
+		for _, tc := range []struct{ n, m int }{{0, 1}, {1, 2}, {20, 5}, {33, 6}, {7, 12}} {
+			if err := checkNonNegative(compute(tc.n, tc.m).Totals); err != nil {
+				t.Errorf("%s backend, n = %d, m = %d: %v", name, tc.n, tc.m, err)
+			}
+		}
+	}
+	if err := checkNonNegative(ResidueDistribution(9, 4).WithoutEmpty().Totals); err != nil {
+		t.Error(err)
+	}
+
+	err := checkNonNegative([]*big.Int{big.NewInt(3), big.NewInt(-1), big.NewInt(0)})
+	if !errors.Is(err, ErrInternalCheckFailed) {
+		t.Errorf("negative count gave %v, want an error wrapping ErrInternalCheckFailed", err)
+	}
+}
+
+func TestCyclicPolyProductMatchesResidueDistribution(t *testing.T) {
+	for _, tc := range []struct{ n, m int }{{0, 1}, {0, 4}, {1, 1}, {13, 3}, {2000, 5}, {100, 17}, {5, 12}} {
+		if got, want := cyclicPolyProduct(tc.n, tc.m), ResidueDistribution(tc.n, tc.m).Totals; !sameCounts(got, want) {
+			t.Errorf("cyclicPolyProduct(%d, %d) = %v, want %v", tc.n, tc.m, got, want)
+		}
+	}
+}
+
+func TestSignedSubsetCount(t *testing.T) {
+	if got := SignedSubsetCount(0); got.Cmp(big.NewInt(1)) != 0 {
+		t.Errorf("SignedSubsetCount(0) = %v, want 1", got)
+	}
+	for n := 1; n <= 30; n++ {
+		if got := SignedSubsetCount(n); got.Sign() != 0 {
+			t.Errorf("SignedSubsetCount(%d) = %v, want 0", n, got)
+		}
+	}
+}
+
+func TestEvaluateSubsetPolynomialBruteForce(t *testing.T) {
+	for n := 0; n <= 10; n++ {
+		for _, x := range []int64{-3, -2, -1, 0, 1, 2, 5} {
+			want := new(big.Int)
+			forEachSubset(oneTo(n), func(subset []int) {
+				want.Add(want, new(big.Int).Exp(big.NewInt(x), big.NewInt(int64(sumOf(subset))), nil))
+			})
+			if got := EvaluateSubsetPolynomial(n, x); got.Cmp(want) != 0 {
+				t.Errorf("EvaluateSubsetPolynomial(%d, %d) = %v, want %v", n, x, got, want)
+			}
+		}
+	}
+	if got, want := EvaluateSubsetPolynomial(40, 1), new(big.Int).Lsh(big.NewInt(1), 40); got.Cmp(want) != 0 {
+		t.Errorf("EvaluateSubsetPolynomial(40, 1) = %v, want 2^40", got)
+	}
+}
+
+// gaussianBinomials returns the coefficients of the Gaussian binomials [n choose k]_q for k = 0 ... n,
+// from the q-Pascal rule [n choose k] = [n-1 choose k-1] + q^k [n-1 choose k]
+func gaussianBinomials(n int) [][]int64 {
+	row := [][]int64{{1}}
+	for i := 1; i <= n; i++ {
+		next := make([][]int64, i+1)
+		for k := 0; k <= i; k++ {
+			coeffs := make([]int64, k*(i-k)+1)
+			if k > 0 {
+				for d, c := range row[k-1] {
+					coeffs[d] += c
+				}
+			}
+			if k < i {
+				for d, c := range row[k] {
+					coeffs[d+k] += c
+				}
+			}
+			next[k] = coeffs
+		}
+		row = next
+	}
+	return row
+}
+
+func TestQSubsetDistribution(t *testing.T) {
+	for n := 0; n <= 14; n++ {
+		coeffs := QSubsetDistribution(n)
+		if len(coeffs) != n*(n+1)/2+1 {
+			t.Fatalf("n = %d: %d coefficients, want %d", n, len(coeffs), n*(n+1)/2+1)
+		}
+		for s, top := 0, len(coeffs)-1; s < top; s, top = s+1, top-1 {
+			if coeffs[s].Cmp(coeffs[top]) != 0 {
+				t.Errorf("n = %d: coefficient %d is %v but coefficient %d is %v", n, s, coeffs[s], top, coeffs[top])
+			}
+		}
+
+		// the q-binomial theorem, sum over k of q^(k(k+1)/2) [n choose k]_q
+		want := make([]int64, len(coeffs))
+		for k, g := range gaussianBinomials(n) {
+			for d, c := range g {
+				want[k*(k+1)/2+d] += c
+			}
+		}
+		for s, c := range coeffs {
+			if c.Cmp(big.NewInt(want[s])) != 0 {
+				t.Errorf("n = %d: coefficient of q^%d is %v, the q-binomial theorem gives %d", n, s, c, want[s])
+			}
+		}
+	}
+}
+
+func TestCloneIsIndependent(t *testing.T) {
+	orig := ResidueDistribution(30, 7)
+	want := orig.Clone()
+
+	c := orig.Clone()
+	if !DistributionsEqual(*c, *orig) || c.N != orig.N {
+		t.Fatalf("clone %+v differs from the original %+v", c, orig)
+	}
+	for _, v := range c.Totals {
+		v.SetInt64(-1)
+	}
+	c.Totals[0] = big.NewInt(5)
+	c.N = 1
+	if !DistributionsEqual(*orig, *want) || orig.N != 30 {
+		t.Errorf("changing the clone changed the original to %+v", orig)
+	}
+
+	// the cached results hand out copies in the same way
+	p, err := Prepare(30, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Count(0).SetInt64(0)
+	if got := p.Count(0); got.Cmp(want.Totals[0]) != 0 {
+		t.Errorf("changing a count from Prepared changed it to %v", got)
+	}
+	Binomials(10)[5].SetInt64(0)
+	if got := Binomials(10)[5]; got.Cmp(big.NewInt(252)) != 0 {
+		t.Errorf("changing a cached binomial changed C(10, 5) to %v", got)
+	}
+}
+
+func TestModuliAboveFractionBruteForce(t *testing.T) {
+	for _, n := range []int{0, 1, 6, 10} {
+		for _, threshold := range []*big.Rat{big.NewRat(0, 1), big.NewRat(1, 7), big.NewRat(1, 5), big.NewRat(1, 3), big.NewRat(1, 2), big.NewRat(1, 1)} {
+			var want []int
+			for m := 2; m <= 12; m++ {
+				count := int64(0)
+				forEachSubset(oneTo(n), func(subset []int) {
+					if sumOf(subset)%m == 0 {
+						count++
+					}
+				})
+				if big.NewRat(count, 1<<n).Cmp(threshold) > 0 {
+					want = append(want, m)
+				}
+			}
+			if got := ModuliAboveFraction(n, 12, threshold); fmt.Sprint(got) != fmt.Sprint(want) {
+				t.Errorf("ModuliAboveFraction(%d, 12, %v) = %v, want %v", n, threshold, got, want)
+			}
+		}
+	}
+	if got := ModuliAboveFraction(10, 1, big.NewRat(0, 1)); len(got) != 0 {
+		t.Errorf("no moduli in [2, 1], got %v", got)
+	}
+}
+
+func TestCombineDistributionsSplitsRange(t *testing.T) {
+	for _, tc := range []struct{ n, m int }{{0, 1}, {10, 5}, {2000, 5}, {100, 7}, {31, 12}} {
+		for _, k := range []int{0, tc.n / 3, tc.n} {
+			c := NewCounter(tc.m)
+			for i := k + 1; i <= tc.n; i++ {
+				c.Add(i)
+			}
+			got := CombineDistributions(ResidueDistribution(k, tc.m).Totals, c.Distribution(), tc.m)
+			if want := ResidueDistribution(tc.n, tc.m).Totals; !sameCounts(got, want) {
+				t.Errorf("n = %d, m = %d, split at %d: %v, want %v", tc.n, tc.m, k, got, want)
+			}
+		}
+	}
+
+	two := ResidueDistribution(4, 2).Totals
+	if err := panicError(func() { CombineDistributions(two, ResidueDistribution(4, 3).Totals, 3) }); !errors.Is(err, ErrResidueCountLengthMismatch) {
+		t.Errorf("distributions of different lengths gave %v, want ErrResidueCountLengthMismatch", err)
+	}
+}
+
+func TestCountMiddleResidue(t *testing.T) {
+	for _, tc := range []struct{ n, m int }{{2000, 5}, {100, 8}, {31, 1}} {
+		if got, want := CountMiddleResidue(tc.n, tc.m), ResidueDistribution(tc.n, tc.m).Totals[tc.m/2]; got.Cmp(want) != 0 {
+			t.Errorf("CountMiddleResidue(%d, %d) = %v, want totals[%d] = %v", tc.n, tc.m, got, tc.m/2, want)
+		}
+	}
+	for n := 0; n <= 10; n++ {
+		for m := 1; m <= 7; m++ {
+			if got, want := CountMiddleResidue(n, m), bruteForceDistribution(oneTo(n), m)[m/2]; got.Cmp(want) != 0 {
+				t.Errorf("CountMiddleResidue(%d, %d) = %v, want %v", n, m, got, want)
+			}
+		}
+	}
+}
+
+// TestBinomialsConcurrent calls Binomials from many goroutines at once, for counts nothing else has cached yet,
+// changing the copies it gets. Run it with -race to check the cache for data races
+func TestBinomialsConcurrent(t *testing.T) {
+	defer noBinomialCache.Store(noBinomialCache.Load())
+	for _, noCache := range []bool{false, true} {
+		noBinomialCache.Store(noCache)
+		base := 300
+		if noCache {
+			base = 310
+		}
+		// the expected rows, from Pascal's rule
+		want := map[int][]*big.Int{0: {big.NewInt(1)}}
+		for count := 1; count < base+10; count++ {
+			row := make([]*big.Int, count+1)
+			for k := range row {
+				row[k] = new(big.Int)
+				if k > 0 {
+					row[k].Add(row[k], want[count-1][k-1])
+				}
+				if k < count {
+					row[k].Add(row[k], want[count-1][k])
+				}
+			}
+			want[count] = row
+		}
+		var wg sync.WaitGroup
+		errs := make(chan error, 64)
+		for g := 0; g < 16; g++ {
+			wg.Add(1)
+			go func(g int) {
+				defer wg.Done()
+				for i := 0; i < 20; i++ {
+					count := base + (g+i)%10
+					vals := Binomials(count)
+					for k, v := range vals {
+						if v.Cmp(want[count][k]) != 0 {
+							errs <- fmt.Errorf("C(%d, %d) = %v, want %v", count, k, v, want[count][k])
+							return
+						}
+						// the copy is the caller's, so this must not affect other goroutines
+						v.SetInt64(-1)
+					}
+				}
+			}(g)
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			t.Errorf("no cache %t: %v", noCache, err)
+		}
+	}
+}
+
+func TestRangeNonzero(t *testing.T) {
+	// {1, 2} modulo 7 has the sums 0, 1, 2 and 3
+	dist := ResidueDistribution(2, 7).Totals
+	var residues []int
+	RangeNonzero(dist, func(r int, c *big.Int) bool {
+		if c.Cmp(dist[r]) != 0 {
+			t.Errorf("residue %d passed %v, want %v", r, c, dist[r])
+		}
+		residues = append(residues, r)
+		return true
+	})
+	if fmt.Sprint(residues) != "[0 1 2 3]" {
+		t.Errorf("visited %v, want [0 1 2 3]", residues)
+	}
+
+	// stops as soon as f returns false
+	residues = nil
+	RangeNonzero(dist, func(r int, c *big.Int) bool {
+		residues = append(residues, r)
+		return r < 1
+	})
+	if fmt.Sprint(residues) != "[0 1]" {
+		t.Errorf("visited %v before stopping, want [0 1]", residues)
+	}
+
+	RangeNonzero(make([]*big.Int, 0), func(int, *big.Int) bool {
+		t.Error("called for an empty distribution")
+		return true
+	})
+}
+
+func TestContributionMatrix(t *testing.T) {
+	for _, tc := range []struct{ n, m int }{{2000, 5}, {100, 7}, {10, 12}, {0, 3}, {9, 1}} {
+		sums := ContributionMatrix(tc.n, tc.m)
+		if len(sums) != tc.m {
+			t.Fatalf("n = %d, m = %d: %d rows", tc.n, tc.m, len(sums))
+		}
+		dist := make([]*big.Int, tc.m)
+		for r := range dist {
+			dist[r] = new(big.Int)
+		}
+		dist[0].SetInt64(1)
+		for v, row := range sums {
+			total := new(big.Int)
+			for _, c := range row {
+				total.Add(total, c)
+			}
+			length := RangeResidueCounts(tc.n, tc.m)[v]
+			if want := new(big.Int).Lsh(big.NewInt(1), uint(length)); total.Cmp(want) != 0 {
+				t.Errorf("n = %d, m = %d: row %d adds up to %v, want 2^%d", tc.n, tc.m, v, total, length)
+			}
+			dist = convolve(dist, row)
+		}
+		if want := ResidueDistribution(tc.n, tc.m).Totals; !sameCounts(dist, want) {
+			t.Errorf("n = %d, m = %d: convolving the rows gives %v, want %v", tc.n, tc.m, dist, want)
+		}
+
+		// the caller's own copy
+		if tc.n > 0 {
+			sums[0][0].SetInt64(-1)
+			if ContributionMatrix(tc.n, tc.m)[0][0].Sign() < 0 {
+				t.Errorf("n = %d, m = %d: changing the matrix changed the next one", tc.n, tc.m)
+			}
+		}
+	}
+}
+
+// randomCounts returns m random counts of up to 'bits' bits, about a third of them zero
+func randomCounts(rng *rand.Rand, m int, bits uint) []*big.Int {
+	limit := new(big.Int).Lsh(big.NewInt(1), bits)
+	v := make([]*big.Int, m)
+	for i := range v {
+		v[i] = new(big.Int)
+		if rng.Intn(3) > 0 {
+			v[i].Rand(rng, limit)
+		}
+	}
+	return v
+}
+
+// cloneCounts returns a copy of v with its own big.Ints
+func cloneCounts(v []*big.Int) []*big.Int {
+	c := make([]*big.Int, len(v))
+	for i, x := range v {
+		c[i] = new(big.Int).Set(x)
+	}
+	return c
+}
+
+func TestCyclicConvolve(t *testing.T) {
+	rng := rand.New(rand.NewSource(168))
+	for _, m := range []int{1, 2, 5, 12, 31} {
+		a, b := randomCounts(rng, m, 200), randomCounts(rng, m, 100)
+		want := convolve(a, b)
+
+		dst := randomCounts(rng, m, 50)
+		cyclicConvolve(dst, a, b)
+		if !sameCounts(dst, want) {
+			t.Errorf("m = %d: %v, want %v", m, dst, want)
+		}
+
+		// in place, as a or b, or both
+		x := cloneCounts(a)
+		cyclicConvolve(x, x, b)
+		if !sameCounts(x, want) {
+			t.Errorf("m = %d, dst is a: %v, want %v", m, x, want)
+		}
+		y := cloneCounts(b)
+		cyclicConvolve(y, a, y)
+		if !sameCounts(y, want) {
+			t.Errorf("m = %d, dst is b: %v, want %v", m, y, want)
+		}
+		z := cloneCounts(a)
+		cyclicConvolve(z, z, z)
+		if square := convolve(a, a); !sameCounts(z, square) {
+			t.Errorf("m = %d, dst is a and b: %v, want %v", m, z, square)
+		}
+	}
+
+	cyclicConvolve(nil, nil, nil)
+	err := panicError(func() { cyclicConvolve(make([]*big.Int, 3), make([]*big.Int, 3), make([]*big.Int, 4)) })
+	if !errors.Is(err, ErrResidueCountLengthMismatch) {
+		t.Errorf("mismatched lengths gave %v, want ErrResidueCountLengthMismatch", err)
+	}
+}
+
+// BenchmarkCyclicConvolve convolves two rows of the sums matrix for m = 5 with 400 rows into a preallocated vector,
+// which after the first call only allocates the temporary, and compares it with convolve, which allocates the result
+func BenchmarkCyclicConvolve(b *testing.B) {
+	sums := ContributionMatrix(rows*columns, columns)
+	b.Run("cyclicConvolve", func(b *testing.B) {
+		dst := make([]*big.Int, columns)
+		for i := range dst {
+			dst[i] = new(big.Int)
+		}
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			cyclicConvolve(dst, sums[1], sums[2])
+		}
+	})
+	b.Run("convolve", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			convolve(sums[1], sums[2])
+		}
+	})
+}
+
+func TestDivisibleProbabilityMoreBitsCloser(t *testing.T) {
+	for _, tc := range []struct{ n, m int }{{2000, 5}, {300, 7}} {
+		exact := DivisibleFraction(tc.n, tc.m)
+		var prev *big.Rat
+		for _, prec := range []uint{24, 53, 128, 512, 2048} {
+			value, _ := DivisibleProbability(tc.n, tc.m, prec).Rat(nil)
+			diff := new(big.Rat).Sub(exact, value)
+			diff.Abs(diff)
+			if prev != nil && prev.Sign() != 0 && diff.Cmp(prev) >= 0 {
+				t.Errorf("n = %d, m = %d: %d bits are %v away from the exact fraction, no closer than fewer bits", tc.n, tc.m, prec, diff)
+			}
+			prev = diff
+		}
+		// the fraction's denominator is 2^n, so enough bits hold it exactly
+		if prev.Sign() != 0 {
+			t.Errorf("n = %d, m = %d: 2048 bits are still %v away from the exact fraction", tc.n, tc.m, prev)
+		}
+	}
+}
+
+func TestAllTargetsMatchesCountWithResidue(t *testing.T) {
+	for _, tc := range []struct{ n, m int }{{2000, 5}, {70000, 5}, {300, 7}, {0, 4}, {33, 1}} {
+		all := AllTargets(tc.n, tc.m)
+		if len(all) != tc.m {
+			t.Fatalf("n = %d, m = %d: %d targets", tc.n, tc.m, len(all))
+		}
+		for r := 0; r < tc.m; r++ {
+			if want := CountWithResidue(tc.n, tc.m, r); all[r].Cmp(want) != 0 {
+				t.Errorf("n = %d, m = %d: AllTargets gives %v for residue %d, CountWithResidue %v", tc.n, tc.m, all[r], r, want)
+			}
+		}
+	}
+}
+
+func TestWithoutEmpty(t *testing.T) {
+	for name, compute := range backends {
+		for _, tc := range []struct{ n, m int }{{0, 1}, {0, 3}, {20, 5}, {33, 6}, {7, 12}} {
+			res := compute(tc.n, tc.m)
+			orig := res.Clone()
+			got := res.WithoutEmpty()
+
+			want := orig.Clone()
+			want.Totals[0].Sub(want.Totals[0], big.NewInt(1))
+			if !sameCounts(got.Totals, want.Totals) {
+				t.Errorf("%s backend, n = %d, m = %d: %v, want %v", name, tc.n, tc.m, got.Totals, want.Totals)
+			}
+			if !sameCounts(res.Totals, orig.Totals) {
+				t.Errorf("%s backend, n = %d, m = %d: WithoutEmpty changed the result to %v", name, tc.n, tc.m, res.Totals)
+			}
+			// the nonempty subsets add up to 2^n - 1
+			sum := new(big.Int)
+			for _, c := range got.Totals {
+				sum.Add(sum, c)
+			}
+			if all := new(big.Int).Lsh(big.NewInt(1), uint(tc.n)); sum.Cmp(all.Sub(all, big.NewInt(1))) != 0 {
+				t.Errorf("%s backend, n = %d, m = %d: %v nonempty subsets, want 2^%d - 1", name, tc.n, tc.m, sum, tc.n)
+			}
+		}
+	}
+
+	for _, r := range []*Result{
+		// no empty subset to take out
+		{N: 1, M: 2, Totals: []*big.Int{big.NewInt(0), big.NewInt(2)}},
+		// counts that don't add up to 2^N
+		{N: 2, M: 2, Totals: []*big.Int{big.NewInt(2), big.NewInt(1)}},
+	} {
+		if err := panicError(func() { r.WithoutEmpty() }); !errors.Is(err, ErrInternalCheckFailed) {
+			t.Errorf("WithoutEmpty of %v gave %v, want ErrInternalCheckFailed", r.Totals, err)
+		}
+	}
+}
+
+func TestResidueDifferences(t *testing.T) {
+	for _, tc := range []struct{ n, m int }{{0, 1}, {0, 4}, {10, 3}, {20, 7}, {300, 12}} {
+		totals := ResidueDistribution(tc.n, tc.m).Totals
+		if tc.n <= 20 {
+			totals = bruteForceDistribution(oneTo(tc.n), tc.m)
+		}
+		diffs := ResidueDifferences(tc.n, tc.m)
+		if len(diffs) != tc.m {
+			t.Fatalf("n = %d, m = %d: %d differences, want %d", tc.n, tc.m, len(diffs), tc.m)
+		}
+		sum := new(big.Int)
+		for r, d := range diffs {
+			want := new(big.Int).Sub(totals[(r+1)%tc.m], totals[r])
+			if d.Cmp(want) != 0 {
+				t.Errorf("n = %d, m = %d: difference %d is %v, want %v", tc.n, tc.m, r, d, want)
+			}
+			sum.Add(sum, d)
+		}
+		if sum.Sign() != 0 {
+			t.Errorf("n = %d, m = %d: the differences sum to %v, not 0", tc.n, tc.m, sum)
+		}
+	}
+}
+
+func TestIsDivisibleCountAtLeast(t *testing.T) {
+	for _, tc := range []struct{ n, m int }{{0, 1}, {0, 5}, {10, 3}, {3000, 7}, {50, 12}} {
+		count := ResidueDistribution(tc.n, tc.m).Totals[0]
+		one := big.NewInt(1)
+		for _, c := range []struct {
+			t    *big.Int
+			want bool
+		}{
+			{count, true},
+			{new(big.Int).Sub(count, one), true},
+			{new(big.Int).Add(count, one), false},
+			{new(big.Int).Lsh(count, 1), false},
+			{new(big.Int), true},
+			{big.NewInt(-1), true},
+		} {
+			if got := IsDivisibleCountAtLeast(tc.n, tc.m, c.t); got != c.want {
+				t.Errorf("IsDivisibleCountAtLeast(%d, %d, %v) = %v, want %v", tc.n, tc.m, c.t, got, c.want)
+			}
+		}
+	}
+	if err := panicError(func() { IsDivisibleCountAtLeast(10, 0, big.NewInt(1)) }); !errors.Is(err, ErrInvalidModulus) {
+		t.Errorf("a modulus of 0 gave %v, want %v", err, ErrInvalidModulus)
+	}
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// gcd returns the greatest common divisor of a and b, with gcd(0, b) = b
+func gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	if a < 0 {
+		return -a
+	}
+	return a
+}
+
+// CountByGcdWithModulus counts the subsets of {1,...,n} whose sum s has gcd(s, m) = g, where g divides m.
+// The empty sum 0 has gcd(0, m) = m.
+//
+// Let D(d) be the number of subsets whose sum is divisible by d, for d dividing m, read off the residue
+// distribution modulo m. A sum has gcd(s, m) = g exactly when g | s and gcd(s/g, m/g) = 1, so by
+// Mobius inversion over the divisors e of m/g, the count is the sum of mu(e) * D(g*e)
+func CountByGcdWithModulus(n, m, g int) *big.Int {
+	checkModulus(m)
+	if g < 1 || m%g != 0 {
+		panic(fmt.Errorf("%w: %d does not divide %d", ErrInvalidModulus, g, m))
+	}
+	totals := ResidueDistribution(n, m).Totals
+
+	// divisibleBy returns D(d) for d dividing m
+	divisibleBy := func(d int) *big.Int {
+		count := new(big.Int)
+		for r := 0; r < m; r += d {
+			count.Add(count, totals[r])
+		}
+		return count
+	}
+
+	count := new(big.Int)
+	q := m / g
+	for e := 1; e <= q; e++ {
+		if q%e != 0 {
+			continue
+		}
+		switch mobius(e) {
+		case 1:
+			count.Add(count, divisibleBy(g*e))
+		case -1:
+			count.Sub(count, divisibleBy(g*e))
+		}
+	}
+	return count
+}
+
+// mobius returns the Mobius function of e >= 1
+func mobius(e int) int {
+	mu := 1
+	for p := 2; p*p <= e; p++ {
+		if e%p != 0 {
+			continue
+		}
+		e /= p
+		if e%p == 0 {
+			return 0
+		}
+		mu = -mu
+	}
+	if e > 1 {
+		mu = -mu
+	}
+	return mu
+}
+
+// CountMultiConstraint counts the subsets of {1,...,n} whose sum s satisfies s = R (mod M) for every constraint.
+// Whether s satisfies all of them only depends on s modulo the lcm of the moduli, so the distribution is computed
+// modulo the lcm once and the residues satisfying every constraint are added up. This is the Chinese remainder
+// theorem when the moduli are coprime, and also handles overlapping moduli, where inconsistent constraints give 0
+func CountMultiConstraint(n int, constraints []struct{ M, R int }) *big.Int {
+	l := 1
+	for _, c := range constraints {
+		checkModulus(c.M)
+		checkResidue(c.R, c.M)
+		l = l / gcd(l, c.M) * c.M
+	}
+
+	totals := ResidueDistribution(n, l).Totals
+	count := new(big.Int)
+	for s, t := range totals {
+		ok := true
+		for _, c := range constraints {
+			if s%c.M != c.R {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			count.Add(count, t)
+		}
+	}
+	return count
+}
+
+// maxExcludedMultiples bounds CountDivisibleButNotBy, whose inclusion-exclusion visits every subset of the multiples
+const maxExcludedMultiples = 24
+
+// CountDivisibleButNotBy counts the subsets of {1,...,n} whose sum is divisible by m but by none of excludeMultiples,
+// each of which must be a multiple of m. By inclusion-exclusion this is the sum over each set S of the excluded
+// multiples of (-1)^|S| * D(lcm(m, S)), where D(d) is the number of subsets whose sum is divisible by d. Every such
+// lcm divides the lcm of them all, so as in CountByGcdWithModulus the D(d) are read off one distribution modulo that
+func CountDivisibleButNotBy(n, m int, excludeMultiples []int) *big.Int {
+	checkModulus(m)
+	if len(excludeMultiples) > maxExcludedMultiples {
+		panic(fmt.Errorf("%w: %d excluded multiples, the limit is %d", ErrTooManyConstraints, len(excludeMultiples), maxExcludedMultiples))
+	}
+	l := m
+	for _, e := range excludeMultiples {
+		if e < 1 || e%m != 0 {
+			panic(fmt.Errorf("%w: %d is not a multiple of %d", ErrInvalidModulus, e, m))
+		}
+		l = l / gcd(l, e) * e
+	}
+	totals := ResidueDistribution(n, l).Totals
+
+	count := new(big.Int)
+	for mask := 0; mask < 1<<len(excludeMultiples); mask++ {
+		d, sign := m, 1
+		for i, e := range excludeMultiples {
+			if mask&(1<<i) != 0 {
+				d = d / gcd(d, e) * e
+				sign = -sign
+			}
+		}
+		term := new(big.Int)
+		for r := 0; r < l; r += d {
+			term.Add(term, totals[r])
+		}
+		if sign > 0 {
+			count.Add(count, term)
+		} else {
+			count.Sub(count, term)
+		}
+	}
+	return count
+}
+
+// JointDistribution returns the number of subsets of {1,...,n} whose sum s has s = r1 (mod m1) and s = r2 (mod m2),
+// indexed by [r1][r2]. As in CountMultiConstraint the pair only depends on s modulo the lcm of m1 and m2, so
+// the distribution modulo the lcm is computed once and each residue is added to its pair. When m1 and m2 are
+// coprime every pair comes from exactly one residue modulo m1*m2, otherwise the inconsistent pairs are 0
+func JointDistribution(n, m1, m2 int) [][]*big.Int {
+	checkModulus(m1)
+	checkModulus(m2)
+	totals := ResidueDistribution(n, m1/gcd(m1, m2)*m2).Totals
+
+	joint := make([][]*big.Int, m1)
+	for r1 := range joint {
+		joint[r1] = make([]*big.Int, m2)
+		for r2 := range joint[r1] {
+			joint[r1][r2] = new(big.Int)
+		}
+	}
+	for s, t := range totals {
+		joint[s%m1][s%m2].Add(joint[s%m1][s%m2], t)
+	}
+	return joint
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func TestCountByGcdWithModulusBruteForce(t *testing.T) {
+	for n := 0; n <= 11; n++ {
+		for m := 1; m <= 12; m++ {
+			total := new(big.Int)
+			for g := 1; g <= m; g++ {
+				if m%g != 0 {
+					continue
+				}
+				want := countBruteForce(oneTo(n), func(subset []int) bool { return gcd(sumOf(subset), m) == g })
+				got := CountByGcdWithModulus(n, m, g)
+				if got.Cmp(want) != 0 {
+					t.Errorf("CountByGcdWithModulus(%d, %d, %d) = %v, want %v", n, m, g, got, want)
+				}
+				total.Add(total, got)
+			}
+			if !isPowerOfTwo(total, n) {
+				t.Errorf("n = %d, m = %d: the counts over the divisors add up to %v, not 2^%d", n, m, total, n)
+			}
+		}
+	}
+	if err := panicError(func() { CountByGcdWithModulus(10, 12, 5) }); !errors.Is(err, ErrInvalidModulus) {
+		t.Errorf("g = 5 for m = 12 gave %v, want ErrInvalidModulus", err)
+	}
+}
+
+func TestCountMultiConstraintBruteForce(t *testing.T) {
+	type constraint = struct{ M, R int }
+	for _, constraints := range [][]constraint{
+		nil,
+		{{3, 0}},
+		{{3, 0}, {4, 2}},
+		{{4, 1}, {6, 3}},
+		{{4, 1}, {6, 2}},
+		{{2, 0}, {4, 2}, {5, 4}},
+		{{1, 0}, {7, 3}, {7, 3}},
+	} {
+		for _, n := range []int{0, 5, 11} {
+			want := countBruteForce(oneTo(n), func(subset []int) bool {
+				for _, c := range constraints {
+					if sumOf(subset)%c.M != c.R {
+						return false
+					}
+				}
+				return true
+			})
+			if got := CountMultiConstraint(n, constraints); got.Cmp(want) != 0 {
+				t.Errorf("CountMultiConstraint(%d, %v) = %v, want %v", n, constraints, got, want)
+			}
+		}
+	}
+
+	for _, tc := range []struct {
+		constraints []constraint
+		want        error
+	}{
+		{[]constraint{{0, 0}}, ErrInvalidModulus},
+		{[]constraint{{3, 1}, {5, 5}}, ErrResidueOutOfRange},
+	} {
+		if err := panicError(func() { CountMultiConstraint(10, tc.constraints) }); !errors.Is(err, tc.want) {
+			t.Errorf("constraints %v gave %v, want %v", tc.constraints, err, tc.want)
+		}
+	}
+}
+
+func TestCountDivisibleButNotByBruteForce(t *testing.T) {
+	for _, tc := range []struct {
+		m       int
+		exclude []int
+	}{
+		{5, nil},
+		{5, []int{25, 15}},
+		{3, []int{6, 9, 12}},
+		{2, []int{4, 4}},
+		{1, []int{2, 3, 5, 7}},
+		{4, []int{4}},
+	} {
+		for _, n := range []int{0, 7, 12} {
+			want := countBruteForce(oneTo(n), func(subset []int) bool {
+				s := sumOf(subset)
+				for _, e := range tc.exclude {
+					if s%e == 0 {
+						return false
+					}
+				}
+				return s%tc.m == 0
+			})
+			if got := CountDivisibleButNotBy(n, tc.m, tc.exclude); got.Cmp(want) != 0 {
+				t.Errorf("CountDivisibleButNotBy(%d, %d, %v) = %v, want %v", n, tc.m, tc.exclude, got, want)
+			}
+		}
+	}
+
+	for _, tc := range []struct {
+		exclude []int
+		want    error
+	}{
+		{[]int{10, 12}, ErrInvalidModulus},
+		{[]int{0}, ErrInvalidModulus},
+		{[]int{-5}, ErrInvalidModulus},
+		{make([]int, maxExcludedMultiples+1), ErrTooManyConstraints},
+	} {
+		if err := panicError(func() { CountDivisibleButNotBy(10, 5, tc.exclude) }); !errors.Is(err, tc.want) {
+			t.Errorf("excluding %v gave %v, want %v", tc.exclude, err, tc.want)
+		}
+	}
+}
+
+func TestJointDistribution(t *testing.T) {
+	// for coprime moduli each pair of residues is one residue modulo m1*m2, by the Chinese remainder theorem
+	for _, tc := range []struct{ n, m1, m2 int }{{2000, 5, 3}, {100, 4, 9}, {30, 7, 1}} {
+		joint := JointDistribution(tc.n, tc.m1, tc.m2)
+		for s, want := range ResidueDistribution(tc.n, tc.m1*tc.m2).Totals {
+			if got := joint[s%tc.m1][s%tc.m2]; got.Cmp(want) != 0 {
+				t.Errorf("n = %d: [%d][%d] = %v, want residue %d modulo %d, %v", tc.n, s%tc.m1, s%tc.m2, got, s, tc.m1*tc.m2, want)
+			}
+		}
+	}
+
+	for _, tc := range []struct{ m1, m2 int }{{4, 6}, {3, 3}, {2, 8}, {5, 7}} {
+		for _, n := range []int{0, 6, 11} {
+			joint := JointDistribution(n, tc.m1, tc.m2)
+			for r1 := 0; r1 < tc.m1; r1++ {
+				for r2 := 0; r2 < tc.m2; r2++ {
+					want := countBruteForce(oneTo(n), func(subset []int) bool {
+						s := sumOf(subset)
+						return s%tc.m1 == r1 && s%tc.m2 == r2
+					})
+					if joint[r1][r2].Cmp(want) != 0 {
+						t.Errorf("JointDistribution(%d, %d, %d)[%d][%d] = %v, want %v", n, tc.m1, tc.m2, r1, r2, joint[r1][r2], want)
+					}
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// The subsets handed out by NthDivisibleSubset are ordered colexicographically: each subset is written as its
+// increasing list of elements, and two subsets are compared by their largest elements first. Equivalently,
+// subset S comes before subset T when the sum of 2^(i-1) over S is less than the same sum over T. So the order
+// for {1,...,n} starts {}, {1}, {2}, {1,2}, {3}, {1,3}, ... and every subset without n comes before every subset
+// with n. This ordering is part of the contract and must not change
+
+// NthDivisibleSubset returns the k-th (counting from zero) subset of {1,...,n} whose sum is divisible by m,
+// in colexicographic order, as an increasing list of elements. k must be less than the number of such subsets
+func NthDivisibleSubset(n, m int, k *big.Int) []int {
+	checkModulus(m)
+	checkN(n)
+
+	// prefix[j] is the distribution of the subsets of {1,...,j}
+	prefix := make([][]*big.Int, n+1)
+	c := NewCounter(m)
+	prefix[0] = c.Distribution()
+	for j := 1; j <= n; j++ {
+		c.Add(j)
+		prefix[j] = c.Distribution()
+	}
+	if k.Sign() < 0 || k.Cmp(prefix[n][0]) >= 0 {
+		panic(fmt.Errorf("%w: there are only %v subsets with sum divisible by %d", ErrRankOutOfRange, prefix[n][0], m))
+	}
+
+	// Decide from the largest element down. The subsets of {1,...,j} without j come first, and there are
+	// prefix[j-1][target] of them with the right sum. If k is beyond them, j is in the subset
+	rank := new(big.Int).Set(k)
+	target := 0
+	var elems []int
+	for j := n; j >= 1; j-- {
+		without := prefix[j-1][target]
+		if rank.Cmp(without) < 0 {
+			continue
+		}
+		rank.Sub(rank, without)
+		elems = append(elems, j)
+		target = ((target-j)%m + m) % m
+	}
+
+	// reverse into increasing order
+	for i, j := 0, len(elems)-1; i < j; i, j = i+1, j-1 {
+		elems[i], elems[j] = elems[j], elems[i]
+	}
+	return elems
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+)
+
+func TestNthDivisibleSubsetPinned(t *testing.T) {
+	// the colexicographic order for n = 5 and m = 3, which must stay the same
+	want := [][]int{
+		{}, {1, 2}, {3}, {1, 2, 3}, {2, 4}, {2, 3, 4},
+		{1, 5}, {1, 3, 5}, {4, 5}, {1, 2, 4, 5}, {3, 4, 5}, {1, 2, 3, 4, 5},
+	}
+	for k, w := range want {
+		if got := NthDivisibleSubset(5, 3, big.NewInt(int64(k))); fmt.Sprint(got) != fmt.Sprint(w) {
+			t.Errorf("NthDivisibleSubset(5, 3, %d) = %v, want %v", k, got, w)
+		}
+	}
+	if err := panicError(func() { NthDivisibleSubset(5, 3, big.NewInt(int64(len(want)))) }); !errors.Is(err, ErrRankOutOfRange) {
+		t.Errorf("rank %d gave %v, want ErrRankOutOfRange", len(want), err)
+	}
+	if err := panicError(func() { NthDivisibleSubset(5, 3, big.NewInt(-1)) }); !errors.Is(err, ErrRankOutOfRange) {
+		t.Errorf("rank -1 gave %v, want ErrRankOutOfRange", err)
+	}
+}
+
+func TestNthDivisibleSubsetOrder(t *testing.T) {
+	// the subsets in order of the bit masks with bit i-1 set for element i, keeping the divisible ones
+	for n := 0; n <= 10; n++ {
+		for m := 1; m <= 6; m++ {
+			k := int64(0)
+			forEachSubset(oneTo(n), func(subset []int) {
+				if sumOf(subset)%m != 0 {
+					return
+				}
+				if got := NthDivisibleSubset(n, m, big.NewInt(k)); fmt.Sprint(got) != fmt.Sprint(subset) {
+					t.Errorf("NthDivisibleSubset(%d, %d, %d) = %v, want %v", n, m, k, got, subset)
+				}
+				k++
+			})
+		}
+	}
+}
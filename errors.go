@@ -0,0 +1,61 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Invalid arguments make the counting functions panic with an error wrapping one of these,
+// so a caller that recovers can use errors.Is to find out what went wrong
+var (
+	ErrInvalidModulus      = errors.New("invalid modulus")
+	ErrNegativeN           = errors.New("negative n")
+	ErrNTooLarge           = errors.New("n too large")
+	ErrResidueOutOfRange   = errors.New("residue out of range")
+	ErrElementOutOfRange   = errors.New("element out of range")
+	ErrRankOutOfRange      = errors.New("rank out of range")
+	ErrSizeOutOfRange      = errors.New("size out of range")
+	ErrInternalCheckFailed = errors.New("internal check failed")
+
+	ErrResidueCountLengthMismatch = errors.New("residue count length mismatch")
+	ErrElementNotRemovable        = errors.New("element not removable")
+	ErrDuplicateElement           = errors.New("duplicate element")
+	ErrTooManyConstraints         = errors.New("too many constraints")
+)
+
+// validateModulus returns an error unless m is a usable modulus
+func validateModulus(m int) error {
+	if m < 1 {
+		return fmt.Errorf("%w: must be at least 1, got %d", ErrInvalidModulus, m)
+	}
+	return nil
+}
+
+// validateN returns an error if n is negative
+func validateN(n int) error {
+	if n < 0 {
+		return fmt.Errorf("%w: got %d", ErrNegativeN, n)
+	}
+	return nil
+}
+
+// checkModulus panics unless m is a usable modulus
+func checkModulus(m int) {
+	if err := validateModulus(m); err != nil {
+		panic(err)
+	}
+}
+
+// checkN panics if n is negative
+func checkN(n int) {
+	if err := validateN(n); err != nil {
+		panic(err)
+	}
+}
+
+// checkResidue panics unless 0 <= r < m
+func checkResidue(r, m int) {
+	if r < 0 || r >= m {
+		panic(fmt.Errorf("%w: %d is not in [0, %d)", ErrResidueOutOfRange, r, m))
+	}
+}
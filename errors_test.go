@@ -0,0 +1,60 @@
+package main
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func TestSentinelErrors(t *testing.T) {
+	pairs := make([][2]int, maxForbiddenPairs+1)
+	for i := range pairs {
+		pairs[i] = [2]int{1, 2}
+	}
+	multiples := make([]int, maxExcludedMultiples+1)
+	for i := range multiples {
+		multiples[i] = 10
+	}
+
+	for _, tc := range []struct {
+		name string
+		f    func()
+		want error
+	}{
+		{"modulus 0", func() { ResidueDistribution(10, 0) }, ErrInvalidModulus},
+		{"negative modulus", func() { NewCounter(-2) }, ErrInvalidModulus},
+		{"composite p", func() { CountDivisibleByPrimePower(10, 6, 1) }, ErrInvalidModulus},
+		{"negative n", func() { ResidueDistribution(-1, 5) }, ErrNegativeN},
+		{"negative binomial count", func() { Binomials(-1) }, ErrNegativeN},
+		{"full sum limit", func() { FullSumDistribution(maxFullSumN + 1) }, ErrNTooLarge},
+		{"residue m", func() { NewCounter(3).Count(3) }, ErrResidueOutOfRange},
+		{"negative residue", func() { CountWithResidue(10, 5, -1) }, ErrResidueOutOfRange},
+		{"excluded 0", func() { CountDivisibleExcluding(10, 5, []int{0}) }, ErrElementOutOfRange},
+		{"mandatory n+1", func() { CountWithMandatory(10, 5, []int{11}, 0) }, ErrElementOutOfRange},
+		{"rank", func() { NthDivisibleSubset(3, 2, big.NewInt(100)) }, ErrRankOutOfRange},
+		{"size range", func() { CountDivisibleSizeRange(5, 3, 4, 2) }, ErrSizeOutOfRange},
+		{"repeated mandatory", func() { CountWithMandatory(10, 5, []int{3, 3}, 0) }, ErrDuplicateElement},
+		{"repeated in a set", func() { CountSymmetricDifferenceDivisible([]int{1}, []int{2, 2}, 3) }, ErrDuplicateElement},
+		{"pair of one element", func() { CountDivisibleWithForbiddenPairs(10, 5, [][2]int{{4, 4}}) }, ErrDuplicateElement},
+		{"too many pairs", func() { CountDivisibleWithForbiddenPairs(10, 5, pairs) }, ErrTooManyConstraints},
+		{"too many multiples", func() { CountDivisibleButNotBy(10, 5, multiples) }, ErrTooManyConstraints},
+	} {
+		if err := panicError(tc.f); !errors.Is(err, tc.want) {
+			t.Errorf("%s: got %v, want an error wrapping %v", tc.name, err, tc.want)
+		}
+	}
+
+	// the functions returning errors use the same sentinels
+	if err := validateModulus(0); !errors.Is(err, ErrInvalidModulus) {
+		t.Errorf("validateModulus(0) = %v", err)
+	}
+	if err := validateN(-5); !errors.Is(err, ErrNegativeN) {
+		t.Errorf("validateN(-5) = %v", err)
+	}
+	if _, err := CountTarget("auto", 10, 5, 5); !errors.Is(err, ErrResidueOutOfRange) {
+		t.Errorf("CountTarget with target 5 modulo 5 = %v", err)
+	}
+	if err := validateModulus(1); err != nil {
+		t.Errorf("validateModulus(1) = %v", err)
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math/big"
+)
+
+// leafCounter is a Tracer counting the complete branches of the recursion that land on each residue
+type leafCounter struct {
+	leaves []int
+}
+
+func (c *leafCounter) Enter(level, chosenResidue int, accum *big.Int) {}
+
+func (c *leafCounter) Leaf(finalResidue int, accum *big.Int) {
+	c.leaves[finalResidue]++
+}
+
+// explain writes a short narrative of how the binomial method counts the subsets of {1,...,n}
+// whose sum is divisible by m, using the actual numbers for this n and m
+func explain(w io.Writer, n, m int) {
+	counter := &leafCounter{leaves: make([]int, m)}
+	r := &recurse{n: n, m: m, tracer: counter}
+	r.initialize()
+	r.computeColumnModuloTotals()
+	r.computeTotalsRecursively()
+
+	fmt.Fprintf(w, "Split {1,...,%d} into %d columns by residue modulo %d:\n", n, m, m)
+	for mod, count := range RangeResidueCounts(n, m) {
+		fmt.Fprintf(w, "  column %d has %d elements\n", mod, count)
+	}
+
+	fmt.Fprintf(w, "Choosing k elements of column c adds k*c to the sum modulo %d, so adding up the\n", m)
+	fmt.Fprintln(w, "binomial coefficients C(length, k) by k*c gives the sums matrix. Row c, entry j is")
+	fmt.Fprintf(w, "the number of subsets of column c whose sum is j modulo %d:\n", m)
+	for mod, row := range r.sums {
+		fmt.Fprintf(w, "  row %d:", mod)
+		for _, v := range row {
+			fmt.Fprintf(w, " %s", humanize(v))
+		}
+		fmt.Fprintln(w)
+	}
+
+	fmt.Fprintln(w, "A subset of the whole set is one subset from each column, so picking one entry from each")
+	fmt.Fprintln(w, "row and multiplying them counts the subsets whose sum is the total of the picked entry")
+	fmt.Fprintf(w, "numbers modulo %d. Of the nonzero picks, %d land on residue 0, and their products add up to\n", m, counter.leaves[0])
+	fmt.Fprintln(w, "  totals[0] =", humanize(r.totals[0]))
+	fmt.Fprintf(w, "which is the number of subsets whose sum is divisible by %d.\n", m)
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestExplainGolden(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		n, m int
+	}{{"n10-m3", 10, 3}, {"n2000-m5", 2000, 5}} {
+		var buf bytes.Buffer
+		explain(&buf, tc.n, tc.m)
+		checkGolden(t, "explain-"+tc.name+".golden", buf.Bytes())
+	}
+
+	// the count it arrives at is the real one
+	var buf bytes.Buffer
+	explain(&buf, 10, 3)
+	if want := "totals[0] = " + ResidueDistribution(10, 3).Totals[0].String() + "\n"; !strings.Contains(buf.String(), want) {
+		t.Errorf("explanation for n = 10, m = 3 doesn't end with %q:\n%s", want, buf.String())
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// weightExpr is a parsed weight expression for -weight, such as "i*i + 1". The grammar is
+//
+//	expr   = term { ("+" | "-") term }
+//	term   = factor { "*" factor }
+//	factor = integer | "i" | "(" expr ")" | "-" factor
+//
+// and nothing else is accepted, so evaluating an expression can't do anything but arithmetic
+type weightExpr struct {
+	op          byte // '+', '-', '*', 'n' for negation, 'i' for the variable or '0' for a constant
+	value       int
+	left, right *weightExpr
+}
+
+// eval returns the value of the expression at i, reduced modulo m. Every step is reduced,
+// so large weights can't overflow
+func (e *weightExpr) eval(i, m int) int {
+	var v int
+	switch e.op {
+	case '0':
+		v = e.value % m
+	case 'i':
+		v = i % m
+	case 'n':
+		v = -e.left.eval(i, m)
+	case '+':
+		v = e.left.eval(i, m) + e.right.eval(i, m)
+	case '-':
+		v = e.left.eval(i, m) - e.right.eval(i, m)
+	case '*':
+		v = e.left.eval(i, m) * e.right.eval(i, m)
+	}
+	v %= m
+	if v < 0 {
+		v += m
+	}
+	return v
+}
+
+// weightParser is a recursive descent parser over the tokens of a weight expression
+type weightParser struct {
+	src string
+	pos int
+}
+
+// parseWeightExpr parses a weight expression, returning an error naming the first unsupported token
+func parseWeightExpr(src string) (*weightExpr, error) {
+	p := &weightParser{src: src}
+	e, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek() != 0 {
+		return nil, p.unexpected()
+	}
+	return e, nil
+}
+
+// peek skips spaces and returns the next character, or 0 at the end of the expression
+func (p *weightParser) peek() byte {
+	for p.pos < len(p.src) && (p.src[p.pos] == ' ' || p.src[p.pos] == '\t') {
+		p.pos++
+	}
+	if p.pos == len(p.src) {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+func (p *weightParser) unexpected() error {
+	if p.peek() == 0 {
+		return fmt.Errorf("weight expression %q ends unexpectedly", p.src)
+	}
+	return fmt.Errorf("weight expression %q: unexpected %q at position %d", p.src, p.src[p.pos], p.pos)
+}
+
+func (p *weightParser) expr() (*weightExpr, error) {
+	left, err := p.term()
+	if err != nil {
+		return nil, err
+	}
+	for op := p.peek(); op == '+' || op == '-'; op = p.peek() {
+		p.pos++
+		right, err := p.term()
+		if err != nil {
+			return nil, err
+		}
+		left = &weightExpr{op: op, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *weightParser) term() (*weightExpr, error) {
+	left, err := p.factor()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == '*' {
+		p.pos++
+		right, err := p.factor()
+		if err != nil {
+			return nil, err
+		}
+		left = &weightExpr{op: '*', left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *weightParser) factor() (*weightExpr, error) {
+	switch c := p.peek(); {
+	case c == 'i':
+		p.pos++
+		return &weightExpr{op: 'i'}, nil
+	case c == '-':
+		p.pos++
+		e, err := p.factor()
+		if err != nil {
+			return nil, err
+		}
+		return &weightExpr{op: 'n', left: e}, nil
+	case c == '(':
+		p.pos++
+		e, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ')' {
+			return nil, p.unexpected()
+		}
+		p.pos++
+		return e, nil
+	case c >= '0' && c <= '9':
+		start := p.pos
+		for p.pos < len(p.src) && p.src[p.pos] >= '0' && p.src[p.pos] <= '9' {
+			p.pos++
+		}
+		v, err := strconv.Atoi(p.src[start:p.pos])
+		if err != nil {
+			return nil, fmt.Errorf("weight expression %q: %w", p.src, err)
+		}
+		return &weightExpr{op: '0', value: v}, nil
+	}
+	return nil, p.unexpected()
+}
@@ -0,0 +1,47 @@
+package main
+
+import "testing"
+
+func TestWeightExpr(t *testing.T) {
+	const m = 1000003
+	for _, tc := range []struct {
+		src  string
+		want func(i int) int
+	}{
+		{"i", func(i int) int { return i }},
+		{"i*i + 1", func(i int) int { return i*i + 1 }},
+		{" 3 * ( i - 2 ) * i ", func(i int) int { return 3 * (i - 2) * i }},
+		{"-i + 10 - -2", func(i int) int { return -i + 10 + 2 }},
+		{"2 - 3 - i", func(i int) int { return 2 - 3 - i }},
+		{"i*i*i - 7*i", func(i int) int { return i*i*i - 7*i }},
+		{"((42))", func(i int) int { return 42 }},
+		{"1 + 2*3", func(i int) int { return 7 }},
+	} {
+		e, err := parseWeightExpr(tc.src)
+		if err != nil {
+			t.Errorf("%q: %v", tc.src, err)
+			continue
+		}
+		for _, i := range []int{0, 1, 2, 5, 99, 12345} {
+			want := (tc.want(i)%m + m) % m
+			if got := e.eval(i, m); got != want {
+				t.Errorf("%q at i = %d gives %d, want %d", tc.src, i, got, want)
+			}
+		}
+	}
+
+	// values are reduced at every step, so this doesn't overflow
+	e, err := parseWeightExpr("i*i*i*i*i*i*i*i")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := e.eval(1<<20, 7); got != 2 {
+		t.Errorf("(2^20)^8 modulo 7 is %d, want 2", got)
+	}
+
+	for _, src := range []string{"", "i^2", "x", "2 +", "(i", "i)", "i i", "1e3", "i / 2", "99999999999999999999"} {
+		if _, err := parseWeightExpr(src); err == nil {
+			t.Errorf("%q was accepted", src)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+)
+
+// trialDivisionBound is the largest small prime candidate factorize tries by division before switching to Pollard rho
+const trialDivisionBound = 10000
+
+// factorization is the result of factorize: the prime factors found, in increasing order with repetition,
+// and the composite cofactors that could not be split before the deadline
+type factorization struct {
+	primes    []*big.Int
+	composite []*big.Int
+}
+
+// complete reports whether x was factored completely
+func (f *factorization) complete() bool {
+	return len(f.composite) == 0
+}
+
+// String formats the factorization as, for example, "2^3 * 5 * 7", with any unsplit cofactors at the end
+func (f *factorization) String() string {
+	var parts []string
+	for i := 0; i < len(f.primes); {
+		j := i
+		for j < len(f.primes) && f.primes[j].Cmp(f.primes[i]) == 0 {
+			j++
+		}
+		if j-i == 1 {
+			parts = append(parts, f.primes[i].String())
+		} else {
+			parts = append(parts, fmt.Sprintf("%v^%d", f.primes[i], j-i))
+		}
+		i = j
+	}
+	for _, c := range f.composite {
+		parts = append(parts, "("+c.String()+")")
+	}
+	if len(parts) == 0 {
+		return "1"
+	}
+	return strings.Join(parts, " * ")
+}
+
+// factorize finds the prime factors of x > 0 by trial division by small numbers and then Pollard's rho method,
+// giving up on whatever is still composite once the deadline passes
+func factorize(x *big.Int, deadline time.Time) *factorization {
+	f := &factorization{}
+	rest := new(big.Int).Set(x)
+
+	q, r := new(big.Int), new(big.Int)
+	for d := int64(2); d <= trialDivisionBound; d++ {
+		bd := big.NewInt(d)
+		for {
+			q.QuoRem(rest, bd, r)
+			if r.Sign() != 0 {
+				break
+			}
+			f.primes = append(f.primes, bd)
+			rest.Set(q)
+		}
+	}
+
+	pending := []*big.Int{rest}
+	for len(pending) > 0 {
+		c := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		switch {
+		case c.Cmp(big.NewInt(1)) == 0:
+		case c.ProbablyPrime(20):
+			f.primes = append(f.primes, c)
+		default:
+			d := pollardRho(c, deadline)
+			if d == nil {
+				f.composite = append(f.composite, c)
+				continue
+			}
+			// the smaller factor is split first, since it is the more likely to finish before the deadline
+			e := new(big.Int).Quo(c, d)
+			if d.Cmp(e) > 0 {
+				d, e = e, d
+			}
+			pending = append(pending, e, d)
+		}
+	}
+
+	sort.Slice(f.primes, func(i, j int) bool { return f.primes[i].Cmp(f.primes[j]) < 0 })
+	return f
+}
+
+// pollardRho returns a nontrivial factor of the odd composite c using Brent's variant of Pollard's rho
+// method, or nil if none is found before the deadline
+func pollardRho(c *big.Int, deadline time.Time) *big.Int {
+	// the differences are multiplied together and only checked with a gcd every 'batch' steps
+	const batch = 128
+
+	one := big.NewInt(1)
+	for inc := int64(1); ; inc++ {
+		add := big.NewInt(inc)
+		next := func(v *big.Int) {
+			v.Mul(v, v)
+			v.Add(v, add)
+			v.Mod(v, c)
+		}
+
+		y := big.NewInt(2)
+		x, ys := new(big.Int), new(big.Int)
+		product := big.NewInt(1)
+		diff, g := new(big.Int), big.NewInt(1)
+		for power := 1; g.Cmp(one) == 0; power *= 2 {
+			x.Set(y)
+			for i := 0; i < power; i++ {
+				next(y)
+			}
+			for k := 0; k < power && g.Cmp(one) == 0; k += batch {
+				if time.Now().After(deadline) {
+					return nil
+				}
+				ys.Set(y)
+				for i := 0; i < batch && i < power-k; i++ {
+					next(y)
+					product.Mul(product, diff.Sub(x, y).Abs(diff))
+					product.Mod(product, c)
+				}
+				g.GCD(nil, nil, product, c)
+			}
+		}
+
+		if g.Cmp(c) == 0 {
+			// the batch overshot, so step through it again one at a time
+			for {
+				next(ys)
+				g.GCD(nil, nil, diff.Sub(x, ys).Abs(diff), c)
+				if g.Cmp(one) != 0 {
+					break
+				}
+			}
+		}
+		if g.Cmp(one) != 0 && g.Cmp(c) != 0 {
+			return g
+		}
+		// this polynomial cycled without splitting c, try the next one
+	}
+}
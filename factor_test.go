@@ -0,0 +1,62 @@
+package main
+
+import (
+	"math/big"
+	"testing"
+	"time"
+)
+
+// checkFactorization checks that f multiplies back to x and that its primes are prime
+func checkFactorization(t *testing.T, x *big.Int, f *factorization) {
+	t.Helper()
+	product := big.NewInt(1)
+	for _, p := range f.primes {
+		if !p.ProbablyPrime(20) {
+			t.Errorf("factor %v of %v is not prime", p, x)
+		}
+		product.Mul(product, p)
+	}
+	for _, c := range f.composite {
+		product.Mul(product, c)
+	}
+	if product.Cmp(x) != 0 {
+		t.Errorf("factors %v multiply to %v, not %v", f, product, x)
+	}
+}
+
+func TestFactorize(t *testing.T) {
+	deadline := time.Now().Add(time.Minute)
+	for _, tc := range []struct {
+		x    *big.Int
+		want string
+	}{
+		{big.NewInt(1), "1"},
+		{big.NewInt(97), "97"},
+		// 344 subsets of {1,...,10} have a sum divisible by 3
+		{ResidueDistribution(10, 3).Totals[0], "2^3 * 43"},
+		// 209728 subsets of {1,...,20} have a sum divisible by 5
+		{ResidueDistribution(20, 5).Totals[0], "2^6 * 29 * 113"},
+		// two primes beyond trial division, which Pollard rho has to split
+		{new(big.Int).Mul(big.NewInt(1000003), big.NewInt(999999937)), "1000003 * 999999937"},
+		{new(big.Int).Mul(big.NewInt(10007), big.NewInt(10007)), "10007^2"},
+	} {
+		f := factorize(tc.x, deadline)
+		checkFactorization(t, tc.x, f)
+		if !f.complete() || f.String() != tc.want {
+			t.Errorf("factorize(%v) = %v, complete %t, want %s", tc.x, f, f.complete(), tc.want)
+		}
+	}
+
+	for _, n := range []int{30, 64, 100} {
+		x := ResidueDistribution(n, 7).Totals[0]
+		checkFactorization(t, x, factorize(x, deadline))
+	}
+
+	// with the deadline already passed the small factors are still found, but the large cofactor is left
+	x := new(big.Int).Mul(big.NewInt(12), new(big.Int).Mul(big.NewInt(1000000007), big.NewInt(998244353)))
+	f := factorize(x, time.Now())
+	checkFactorization(t, x, f)
+	if f.complete() || f.String() != "2^2 * 3 * (998244359987710471)" {
+		t.Errorf("factorize(%v) past the deadline = %v, complete %t", x, f, f.complete())
+	}
+}
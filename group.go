@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// CountIdentitySubsets counts the subsets of a list of elements of the finite abelian group
+// Z/factors[0] x Z/factors[1] x ... whose sum is the identity. Element i is the group element
+// elementGroupValues[i], with one coordinate per factor, added componentwise. With a single factor m
+// and elements 1,...,n this is the number of subsets of {1,...,n} whose sum is divisible by m
+func CountIdentitySubsets(elementGroupValues [][]int, factors []int) *big.Int {
+	order := 1
+	for _, f := range factors {
+		checkModulus(f)
+		order *= f
+	}
+
+	// group elements are numbered in mixed radix, the first coordinate varying slowest
+	index := func(coords []int) int {
+		idx := 0
+		for i, f := range factors {
+			c := coords[i] % f
+			if c < 0 {
+				c += f
+			}
+			idx = idx*f + c
+		}
+		return idx
+	}
+	coords := make([]int, len(factors))
+	add := func(a, b int) int {
+		// decode a, add the coordinates of b and encode again
+		for i := len(factors) - 1; i >= 0; i-- {
+			coords[i] = a%factors[i] + b%factors[i]
+			a /= factors[i]
+			b /= factors[i]
+		}
+		return index(coords)
+	}
+
+	prev := make([]*big.Int, order)
+	next := make([]*big.Int, order)
+	for g := range prev {
+		prev[g] = new(big.Int)
+		next[g] = new(big.Int)
+	}
+	prev[0].SetInt64(1)
+
+	for _, v := range elementGroupValues {
+		if len(v) != len(factors) {
+			panic(fmt.Errorf("%w: element %v has %d coordinates, the group has %d", ErrElementOutOfRange, v, len(v), len(factors)))
+		}
+		shift := index(v)
+		for g := range next {
+			next[g].Set(prev[g])
+		}
+		// the subsets containing this element have their sums moved by 'shift'
+		for g, t := range prev {
+			h := add(g, shift)
+			next[h].Add(next[h], t)
+		}
+		prev, next = next, prev
+	}
+	return prev[0]
+}
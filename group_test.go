@@ -0,0 +1,48 @@
+package main
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestCountIdentitySubsetsCyclic(t *testing.T) {
+	for _, tc := range []struct{ n, m int }{{0, 1}, {10, 1}, {20, 5}, {33, 7}, {12, 12}} {
+		values := make([][]int, tc.n)
+		for i := range values {
+			values[i] = []int{i + 1}
+		}
+		if got, want := CountIdentitySubsets(values, []int{tc.m}), ResidueDistribution(tc.n, tc.m).Totals[0]; got.Cmp(want) != 0 {
+			t.Errorf("n = %d, Z/%d: %v, want %v", tc.n, tc.m, got, want)
+		}
+	}
+}
+
+func TestCountIdentitySubsetsProduct(t *testing.T) {
+	// Z/3 x Z/4 is Z/12 by the Chinese remainder theorem, with i going to (i mod 3, i mod 4)
+	values := make([][]int, 30)
+	for i := range values {
+		values[i] = []int{(i + 1) % 3, (i + 1) % 4}
+	}
+	if got, want := CountIdentitySubsets(values, []int{3, 4}), ResidueDistribution(30, 12).Totals[0]; got.Cmp(want) != 0 {
+		t.Errorf("Z/3 x Z/4: %v, want %v", got, want)
+	}
+
+	// Z/2 x Z/4 is not cyclic, so count by brute force, with negative and large coordinates
+	values = [][]int{{1, 0}, {0, 1}, {1, 3}, {-1, 2}, {3, -5}, {0, 2}, {1, 1}, {2, 4}, {1, 7}}
+	want := int64(0)
+	for mask := 0; mask < 1<<len(values); mask++ {
+		a, b := 0, 0
+		for i, v := range values {
+			if mask>>i&1 == 1 {
+				a += v[0]
+				b += v[1]
+			}
+		}
+		if a%2 == 0 && b%4 == 0 {
+			want++
+		}
+	}
+	if got := CountIdentitySubsets(values, []int{2, 4}); got.Cmp(big.NewInt(want)) != 0 {
+		t.Errorf("Z/2 x Z/4: %v, want %d", got, want)
+	}
+}
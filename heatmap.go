@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+// writeFractionData writes one line "m fraction" for each modulus m in [from, to], where fraction is
+// the proportion of subsets of {1,...,n} whose sum is divisible by m, rounded to 'digits' significant digits.
+// The fractions are computed with at least minPrec bits, or more if the digits need them.
+// This is a two column data file for plotting how the proportion approaches 1/m
+func writeFractionData(w io.Writer, n, from, to, digits int, minPrec uint) error {
+	checkModulus(from)
+	bw := bufio.NewWriter(w)
+	// enough bits for the requested digits, plus some to spare
+	prec := uint(float64(digits)*3.33) + 16
+	if prec < minPrec {
+		prec = minPrec
+	}
+	for m := from; m <= to; m++ {
+		fmt.Fprintf(bw, "%d %s\n", m, DivisibleProbability(n, m, prec).Text('g', digits))
+	}
+	return bw.Flush()
+}
+
+// runHeatmap implements the heatmap subcommand, with fractions computed to at least floatPrec bits
+func runHeatmap(args []string, floatPrec uint) error {
+	fs := flag.NewFlagSet("heatmap", flag.ExitOnError)
+	n := fs.Int("n", rows*columns, "count subsets of {1,...,n}")
+	from := fs.Int("from", 2, "smallest modulus")
+	to := fs.Int("to", 20, "largest modulus")
+	digits := fs.Int("digits", 20, "significant digits of each fraction")
+	out := fs.String("o", "", "write the data to this file instead of stdout")
+	fs.Parse(args)
+
+	for _, err := range []error{validateN(*n), validateModulus(*from)} {
+		if err != nil {
+			return err
+		}
+	}
+	if *to < *from {
+		return fmt.Errorf("bad -to %d, must be at least -from %d", *to, *from)
+	}
+	if *digits < 1 {
+		return fmt.Errorf("bad -digits %d, must be at least 1", *digits)
+	}
+
+	if *out == "" {
+		return writeFractionData(os.Stdout, *n, *from, *to, *digits, floatPrec)
+	}
+	f, err := os.Create(*out)
+	if err != nil {
+		return err
+	}
+	if err := writeFractionData(f, *n, *from, *to, *digits, floatPrec); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
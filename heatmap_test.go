@@ -0,0 +1,55 @@
+package main
+
+import (
+	"math/big"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestHeatmapFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "heatmap.dat")
+	if err := runHeatmap([]string{"-n", "12", "-from", "2", "-to", "6", "-digits", "15", "-o", path}, 64); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if len(lines) != 5 {
+		t.Fatalf("%d lines, want one for each m in [2, 6]:\n%s", len(lines), data)
+	}
+	for i, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) != 2 || fields[0] != strconv.Itoa(i+2) {
+			t.Fatalf("line %q, want m = %d and a fraction", line, i+2)
+		}
+		m := i + 2
+		want := countBruteForce(oneTo(12), divisibleBy(m))
+		got, ok := new(big.Rat).SetString(fields[1])
+		if !ok {
+			t.Fatalf("bad fraction %q", fields[1])
+		}
+		// 15 significant digits of the exact fraction want / 2^12
+		diff := new(big.Rat).Sub(got, new(big.Rat).SetFrac(want, big.NewInt(4096)))
+		if diff.Abs(diff).Cmp(big.NewRat(1, 1e15)) > 0 {
+			t.Errorf("m = %d: fraction %s, want %v/4096", m, fields[1], want)
+		}
+	}
+}
+
+func TestHeatmapRejectsBadFlags(t *testing.T) {
+	for _, args := range [][]string{
+		{"-n", "-1"},
+		{"-from", "0"},
+		{"-from", "5", "-to", "4"},
+		{"-digits", "0"},
+	} {
+		if err := runHeatmap(args, 64); err == nil {
+			t.Errorf("heatmap %v did not fail", args)
+		}
+	}
+}
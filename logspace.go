@@ -0,0 +1,107 @@
+package main
+
+import (
+	"math"
+	"math/big"
+)
+
+// atanhFloat returns atanh(z) = z + z^3/3 + z^5/5 + ... to prec bits, for small |z|
+func atanhFloat(z *big.Float, prec uint) *big.Float {
+	work := prec + 32
+	z2 := new(big.Float).SetPrec(work).Mul(z, z)
+	sum := new(big.Float).SetPrec(work)
+	power := new(big.Float).SetPrec(work).Set(z)
+	term := new(big.Float).SetPrec(work)
+	limit := new(big.Float).SetMantExp(big.NewFloat(1), -int(work))
+	for k := int64(1); ; k += 2 {
+		term.Quo(power, new(big.Float).SetInt64(k))
+		sum.Add(sum, term)
+		if term.Sign() == 0 || new(big.Float).Abs(term).Cmp(limit) < 0 {
+			break
+		}
+		power.Mul(power, z2)
+	}
+	return sum.SetPrec(prec)
+}
+
+// expFloat returns e^x to prec bits, for x of moderate size. The series converges slowly for large |x|, so it is
+// summed for x / 2^k and the result squared k times, with k extra bits to absorb the error the squaring amplifies
+func expFloat(x *big.Float, prec uint) *big.Float {
+	const k = 16
+	work := prec + 32 + k
+	y := new(big.Float).SetPrec(work).SetMantExp(x, -k)
+	sum := new(big.Float).SetPrec(work).SetInt64(1)
+	term := new(big.Float).SetPrec(work).SetInt64(1)
+	limit := new(big.Float).SetMantExp(big.NewFloat(1), -int(work))
+	for i := int64(1); ; i++ {
+		term.Mul(term, y)
+		term.Quo(term, new(big.Float).SetInt64(i))
+		sum.Add(sum, term)
+		if term.Sign() == 0 || new(big.Float).Abs(term).Cmp(limit) < 0 {
+			break
+		}
+	}
+	for i := 0; i < k; i++ {
+		sum.Mul(sum, sum)
+	}
+	return sum.SetPrec(prec)
+}
+
+// lnFloat returns the natural logarithm of x > 0 to prec bits. x is written as y * 2^e with y in [1, 2), and then
+// ln(x) = 2 atanh((y - 1)/(y + 1)) + e * ln(2), where ln(2) = 2 atanh(1/3). Both series converge quickly
+// since their arguments are at most 1/3
+func lnFloat(x *big.Float, prec uint) *big.Float {
+	work := prec + 32
+	y := new(big.Float).SetPrec(work)
+	exp := x.MantExp(y)
+	// MantExp gives y in [0.5, 1), move it to [1, 2)
+	y.SetMantExp(y, 1)
+	exp--
+
+	one := new(big.Float).SetPrec(work).SetInt64(1)
+	num := new(big.Float).SetPrec(work).Sub(y, one)
+	den := new(big.Float).SetPrec(work).Add(y, one)
+	ln := atanhFloat(num.Quo(num, den), work)
+	ln.Mul(ln, big.NewFloat(2))
+
+	third := new(big.Float).SetPrec(work).Quo(one, new(big.Float).SetPrec(work).SetInt64(3))
+	ln2 := atanhFloat(third, work)
+	ln2.Mul(ln2, big.NewFloat(2))
+	ln.Add(ln, ln2.Mul(ln2, new(big.Float).SetInt64(int64(exp))))
+	return ln.SetPrec(prec)
+}
+
+// LogspaceEstimate estimates the number of subsets of {1,...,n} whose sum is divisible by m without computing it,
+// returning its number of decimal digits and its leading digits. The count is 2^n/m plus a correction that is
+// vanishingly small in comparison once n is large, so the estimate works with log10(2^n/m) in big.Float arithmetic
+// at prec bits. More bits give more leading digits: the fractional part of the logarithm is only good to about
+// prec bits less the size of its integer part, and a few digits are held back from that. It is approximate:
+// for small n the correction can change the leading digits, or even the digit count
+func LogspaceEstimate(n, m int, prec uint) (digits int64, leading string) {
+	checkModulus(m)
+	checkN(n)
+
+	ln2 := lnFloat(big.NewFloat(2), prec)
+	lnm := lnFloat(new(big.Float).SetInt64(int64(m)), prec)
+	ln10 := lnFloat(big.NewFloat(10), prec)
+
+	// log10(2^n / m)
+	l := new(big.Float).SetPrec(prec).Mul(ln2, new(big.Float).SetInt64(int64(n)))
+	l.Sub(l, lnm)
+	l.Quo(l, ln10)
+
+	whole, _ := l.Int(nil)
+	if l.Sign() < 0 {
+		// the count is at least 1 when rounded, so it has one digit
+		return 1, "1"
+	}
+	frac := new(big.Float).SetPrec(prec).Sub(l, new(big.Float).SetInt(whole))
+
+	// 10^frac = e^(frac * ln(10)), which is in [1, 10)
+	mantissa := expFloat(frac.Mul(frac, ln10), prec)
+	decimals := int(float64(prec)*math.Log10(2)-math.Log10(float64(whole.Int64()+1))) - 3
+	if decimals < 1 {
+		decimals = 1
+	}
+	return whole.Int64() + 1, mantissa.Text('f', decimals)
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func TestLogspaceEstimateMatchesExact(t *testing.T) {
+	for _, tc := range []struct {
+		n, m int
+		prec uint
+	}{{100, 3, 64}, {2000, 5, 64}, {2000, 5, 256}, {5000, 7, 128}, {20000, 12, 128}, {3000, 1, 53}} {
+		exact := ResidueDistribution(tc.n, tc.m).Totals[0]
+		digits, leading := LogspaceEstimate(tc.n, tc.m, tc.prec)
+		if want := len(exact.String()); digits != int64(want) {
+			t.Errorf("n = %d, m = %d: %d digits, want %d", tc.n, tc.m, digits, want)
+			continue
+		}
+
+		// leading is exact / 10^(digits - 1), correct to within a unit in its last decimal place
+		decimals := len(leading) - strings.Index(leading, ".") - 1
+		got, ok := new(big.Rat).SetString(leading)
+		if !ok {
+			t.Fatalf("n = %d, m = %d: leading digits %q don't parse", tc.n, tc.m, leading)
+		}
+		want := new(big.Rat).SetFrac(exact, new(big.Int).Exp(big.NewInt(10), big.NewInt(digits-1), nil))
+		diff := new(big.Rat).Sub(got, want)
+		ulp := new(big.Rat).SetFrac(big.NewInt(1), new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil))
+		if diff.Abs(diff).Cmp(ulp) > 0 {
+			t.Errorf("n = %d, m = %d, prec %d: leading digits %s, want %s", tc.n, tc.m, tc.prec, leading, want.FloatString(decimals))
+		}
+	}
+
+	if digits, leading := LogspaceEstimate(1, 7, 64); digits != 1 || leading != "1" {
+		t.Errorf("n = 1, m = 7: %d digits %q, want 1 digit \"1\"", digits, leading)
+	}
+}
+
+func TestLogspaceEstimateMoreBitsMoreDigits(t *testing.T) {
+	exact := ResidueDistribution(3000, 5).Totals[0].String()
+	prevLen := 0
+	for _, prec := range []uint{64, 128, 256} {
+		_, leading := LogspaceEstimate(3000, 5, prec)
+		digits := strings.Replace(leading, ".", "", 1)
+		if len(digits) <= prevLen {
+			t.Errorf("%d bits give %d leading digits, no more than fewer bits", prec, len(digits))
+		}
+		prevLen = len(digits)
+		// all but the last digit, which is rounded, are the exact count's
+		if !strings.HasPrefix(exact, digits[:len(digits)-1]) {
+			t.Errorf("%d bits give leading digits %s, want %s", prec, digits, exact[:len(digits)])
+		}
+	}
+}
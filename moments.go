@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// ResidueVariance returns the exact variance of (sum mod m) over a uniformly random subset of {1,...,n}.
+// For large n the distribution is almost uniform and the variance approaches (m^2 - 1)/12, the variance
+// of a uniform choice from {0,...,m-1}
+func ResidueVariance(n, m int) *big.Rat {
+	totals := ResidueDistribution(n, m).Totals
+
+	// E[X] and E[X^2] as sums over the distribution, divided by 2^n at the end
+	first := new(big.Int)
+	second := new(big.Int)
+	term := new(big.Int)
+	for r, t := range totals {
+		term.Mul(t, big.NewInt(int64(r)))
+		first.Add(first, term)
+		term.Mul(term, big.NewInt(int64(r)))
+		second.Add(second, term)
+	}
+	total := new(big.Int).Lsh(big.NewInt(1), uint(n))
+
+	mean := new(big.Rat).SetFrac(first, total)
+	variance := new(big.Rat).SetFrac(second, total)
+	return variance.Sub(variance, mean.Mul(mean, mean))
+}
+
+// ExpectedDivisibleCount returns the expected number of subsets with sum divisible by m, when each element i
+// of {1,...,n} is independently available with probability p(i) and only subsets of the available elements count.
+// Each element contributes the factor (1 + p(i) x^i) to the generating function in Q[x]/(x^m - 1), so this is the
+// simple method with rational coefficients. With p(i) = 1 for every i it gives the usual count
+func ExpectedDivisibleCount(n, m int, p func(i int) *big.Rat) *big.Rat {
+	checkModulus(m)
+	checkN(n)
+
+	prev := make([]*big.Rat, m)
+	next := make([]*big.Rat, m)
+	for r := range prev {
+		prev[r] = new(big.Rat)
+		next[r] = new(big.Rat)
+	}
+	prev[0].SetInt64(1)
+
+	term := new(big.Rat)
+	for i := 1; i <= n; i++ {
+		pi := p(i)
+		shift := i % m
+		for col := 0; col < m; col++ {
+			k := (col + shift) % m
+			// subsets without i keep their sum, those with i are shifted and weighted by p(i)
+			next[k].Add(prev[k], term.Mul(pi, prev[col]))
+		}
+		prev, next = next, prev
+	}
+	return prev[0]
+}
+
+// ResidueProbabilities returns, for each residue r, the exact probability that a uniformly random subset
+// of {1,...,n} has a sum congruent to r modulo m, in lowest terms
+func ResidueProbabilities(n, m int) []*big.Rat {
+	totals := ResidueDistribution(n, m).Totals
+	total := new(big.Int).Lsh(big.NewInt(1), uint(n))
+
+	probs := make([]*big.Rat, m)
+	sum := new(big.Rat)
+	for r, t := range totals {
+		probs[r] = new(big.Rat).SetFrac(t, total)
+		sum.Add(sum, probs[r])
+	}
+
+	// the probabilities must add up to exactly 1
+	if sum.Cmp(big.NewRat(1, 1)) != 0 {
+		panic(fmt.Errorf("%w: probabilities add up to %v", ErrInternalCheckFailed, sum))
+	}
+	return probs
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestResidueVarianceBruteForce(t *testing.T) {
+	for n := 0; n <= 10; n++ {
+		for m := 1; m <= 7; m++ {
+			// the mean and mean square of (sum mod m) over all 2^n subsets
+			first, second := new(big.Rat), new(big.Rat)
+			forEachSubset(oneTo(n), func(subset []int) {
+				r := int64(sumOf(subset) % m)
+				first.Add(first, big.NewRat(r, 1))
+				second.Add(second, big.NewRat(r*r, 1))
+			})
+			count := new(big.Rat).SetInt(new(big.Int).Lsh(big.NewInt(1), uint(n)))
+			mean := first.Quo(first, count)
+			want := second.Quo(second, count)
+			want.Sub(want, mean.Mul(mean, mean))
+
+			if got := ResidueVariance(n, m); got.Cmp(want) != 0 {
+				t.Errorf("ResidueVariance(%d, %d) = %v, want %v", n, m, got, want)
+			}
+		}
+	}
+
+	// nearly uniform for large n
+	uniform := big.NewRat(7*7-1, 12)
+	diff := new(big.Rat).Sub(ResidueVariance(200, 7), uniform)
+	if diff.Abs(diff).Cmp(big.NewRat(1, 1e15)) > 0 {
+		t.Errorf("ResidueVariance(200, 7) is %v away from the uniform %v", diff, uniform)
+	}
+}
+
+func TestExpectedDivisibleCountBruteForce(t *testing.T) {
+	one := func(int) *big.Rat { return big.NewRat(1, 1) }
+	for _, tc := range []struct{ n, m int }{{0, 1}, {0, 3}, {10, 5}, {2000, 5}, {100, 7}, {37, 12}} {
+		want := new(big.Rat).SetInt(ResidueDistribution(tc.n, tc.m).Totals[0])
+		if got := ExpectedDivisibleCount(tc.n, tc.m, one); got.Cmp(want) != 0 {
+			t.Errorf("ExpectedDivisibleCount(%d, %d) with p = 1 is %v, want %v", tc.n, tc.m, got, want)
+		}
+	}
+
+	// a subset of the available elements is one whose elements are all available, so each divisible subset
+	// contributes the product of its probabilities
+	p := func(i int) *big.Rat { return big.NewRat(int64(i), int64(i+2)) }
+	for n := 0; n <= 10; n++ {
+		for m := 1; m <= 6; m++ {
+			want := new(big.Rat)
+			forEachSubset(oneTo(n), func(subset []int) {
+				if sumOf(subset)%m != 0 {
+					return
+				}
+				prob := big.NewRat(1, 1)
+				for _, e := range subset {
+					prob.Mul(prob, p(e))
+				}
+				want.Add(want, prob)
+			})
+			if got := ExpectedDivisibleCount(n, m, p); got.Cmp(want) != 0 {
+				t.Errorf("ExpectedDivisibleCount(%d, %d) = %v, want %v", n, m, got, want)
+			}
+		}
+	}
+}
+
+func TestResidueProbabilitiesBruteForce(t *testing.T) {
+	for _, tc := range []struct{ n, m int }{{2000, 5}, {100, 7}, {0, 4}} {
+		sum := new(big.Rat)
+		for _, p := range ResidueProbabilities(tc.n, tc.m) {
+			sum.Add(sum, p)
+		}
+		if sum.Cmp(big.NewRat(1, 1)) != 0 {
+			t.Errorf("n = %d, m = %d: probabilities add up to %v", tc.n, tc.m, sum)
+		}
+	}
+
+	for n := 0; n <= 10; n++ {
+		for m := 1; m <= 7; m++ {
+			dist := bruteForceDistribution(oneTo(n), m)
+			for r, p := range ResidueProbabilities(n, m) {
+				if want := new(big.Rat).SetFrac(dist[r], big.NewInt(1<<n)); p.Cmp(want) != 0 {
+					t.Errorf("n = %d, m = %d: residue %d has probability %v, want %v", n, m, r, p, want)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata with the current output")
+
+// checkGolden compares got with the file testdata/name, or writes it there with -update
+func checkGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.MkdirAll("testdata", 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("output differs from %s, rerun with -update if that is intended:\n%s", path, got)
+	}
+}
+
+func TestWriteSplitOutput(t *testing.T) {
+	// the directory is created, parents included
+	dir := filepath.Join(t.TempDir(), "out", "split")
+	res := ResidueDistribution(20, 6)
+	if err := writeSplitOutput(dir, res); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 6 {
+		t.Errorf("%d files written, want 6", len(entries))
+	}
+	for r, count := range res.Totals {
+		data, err := os.ReadFile(filepath.Join(dir, fmt.Sprintf("residue_%d.txt", r)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := count.String() + "\n"; string(data) != want {
+			t.Errorf("residue_%d.txt holds %q, want %q", r, data, want)
+		}
+	}
+
+	// a file in the way of the directory is an error
+	file := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(file, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeSplitOutput(file, res); err == nil {
+		t.Error("writing into a regular file did not fail")
+	}
+}
+
+func TestPrintBinomialSummary(t *testing.T) {
+	var out bytes.Buffer
+	printBinomialSummary(&out, 7)
+	want := `column length 7 has 8 binomial coefficients
+  C(7, 0) = 1
+  C(7, 1) = 7
+  C(7, 2) = 21
+  ...
+  C(7, 5) = 21
+  C(7, 6) = 7
+  C(7, 7) = 1
+  middle C(7, 3) = 35
+`
+	if out.String() != want {
+		t.Errorf("summary for length 7:\n%s\nwant:\n%s", out.String(), want)
+	}
+
+	out.Reset()
+	printBinomialSummary(&out, 2)
+	if want := "column length 2 has 3 binomial coefficients\n  C(2, 0) = 1\n  C(2, 1) = 2\n  C(2, 2) = 1\n  middle C(2, 1) = 2\n"; out.String() != want {
+		t.Errorf("summary for length 2:\n%s\nwant:\n%s", out.String(), want)
+	}
+}
+
+func TestHumanizeBinomial(t *testing.T) {
+	for _, tc := range []struct {
+		n, k int
+		want string
+	}{
+		{60, 30, "118264581564861424"},
+		// C(400, 200) = 1.029525...e+119
+		{400, 200, "~1.02953e+119 (120 digits)"},
+		// C(200000, 100000) = 1.780562...e+60203
+		{200000, 100000, "~1.78056e+60203 (60204 digits)"},
+	} {
+		if got := humanizeBinomial(tc.n, tc.k); got != tc.want {
+			t.Errorf("humanizeBinomial(%d, %d) = %q, want %q", tc.n, tc.k, got, tc.want)
+		}
+	}
+}
+
+func TestPrintResultGolden(t *testing.T) {
+	defer printDigits.Store(printDigits.Load())
+	for _, tc := range []struct {
+		name   string
+		n, m   int
+		format OutputFormat
+		digits int64
+	}{
+		{"text", 20, 5, FormatText, 0},
+		{"python", 20, 5, FormatPython, 0},
+		{"python-dist", 20, 5, FormatPythonDistribution, 0},
+		{"text-digits", 2000, 5, FormatText, 12},
+		{"python-digits", 200, 3, FormatPython, 12},
+	} {
+		printDigits.Store(tc.digits)
+		var buf bytes.Buffer
+		printHeader(&buf, tc.format, fmt.Sprintf("subsets of {1,...,%d} by sum modulo %d", tc.n, tc.m))
+		printResult(&buf, ResidueDistribution(tc.n, tc.m), tc.format)
+		checkGolden(t, "printresult-"+tc.name+".golden", buf.Bytes())
+	}
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"math/big"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// recursionWorkers is the number of goroutines recursion and recursionContext share the work among, and simple
+// for large moduli. The result is the same for any number of workers
+var recursionWorkers atomic.Int64
+
+func init() {
+	recursionWorkers.Store(int64(runtime.NumCPU()))
+}
+
+// parallelDepth is how many levels of the recursion are expanded into separate branches for the workers.
+// The first row of the sums matrix is the residue 0 column, which only ever contributes 0, so one level
+// alone would give a single branch
+const parallelDepth = 2
+
+// branch is a partial path through the first levels of the recursion, with the residue and accumulator so far
+type branch struct {
+	mod   int
+	accum *big.Int
+}
+
+// expandBranches returns every nonzero branch through the first 'depth' levels, in the order doNextLevel visits them
+func (r *recurse) expandBranches(depth int) []branch {
+	var branches []branch
+	var expand func(level, mod int, accum *big.Int)
+	expand = func(level, mod int, accum *big.Int) {
+		if accum.Sign() == 0 {
+			return
+		}
+		if level == depth {
+			branches = append(branches, branch{mod: mod, accum: accum})
+			return
+		}
+		for n := 0; n < r.m; n++ {
+			expand(level+1, (mod+n)%r.m, new(big.Int).Mul(accum, r.sums[level][n]))
+		}
+	}
+	expand(0, 0, big.NewInt(1))
+	return branches
+}
+
+// runBranch completes the recursion below a branch from expandBranches(depth), returning the totals of that
+// branch alone and whether it stopped early. Only the sums matrix is read, so branches can run concurrently
+func (r *recurse) runBranch(b branch, depth int) ([]*big.Int, bool) {
+	sub := &recurse{n: r.n, m: r.m, sums: r.sums, done: r.done, mod: b.mod, accum: b.accum}
+	sub.totals = make([]*big.Int, r.m)
+	for j := range sub.totals {
+		sub.totals[j] = new(big.Int)
+	}
+	sub.doNextLevel(depth)
+	return sub.totals, sub.stopped
+}
+
+// branchDepth returns how many levels are expanded into branches for the workers and for checkpoints
+func (r *recurse) branchDepth() int {
+	if r.m < parallelDepth {
+		return r.m
+	}
+	return parallelDepth
+}
+
+// computeTotalsInParallel does the work of doNextLevel(0) with the branches below the first parallelDepth levels
+// shared among r.workers goroutines. Each branch adds into its own totals, and these are added up in branch order
+// afterwards, so the result does not depend on the number of workers or on the order in which they finish
+func (r *recurse) computeTotalsInParallel() {
+	depth := r.branchDepth()
+	branches := r.expandBranches(depth)
+
+	partial := make([][]*big.Int, len(branches))
+	var stopped atomic.Bool
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < r.workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				totals, s := r.runBranch(branches[i], depth)
+				partial[i] = totals
+				if s {
+					stopped.Store(true)
+				}
+			}
+		}()
+	}
+	for i := range branches {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	for _, totals := range partial {
+		for j, t := range totals {
+			r.totals[j].Add(r.totals[j], t)
+		}
+	}
+	r.stopped = stopped.Load()
+}
+
+// computeTotalsByBranch does the work of doNextLevel(0) one branch from expandBranches at a time, in order,
+// calling onBranch after each. These are the branches the workers share and checkpoints record, since level 0
+// alone is a single branch that does all the work
+func (r *recurse) computeTotalsByBranch() {
+	depth := r.branchDepth()
+	branches := r.expandBranches(depth)
+	for i, b := range branches {
+		totals, stopped := r.runBranch(b, depth)
+		for j, t := range totals {
+			r.totals[j].Add(r.totals[j], t)
+		}
+		if stopped {
+			r.stopped = true
+			return
+		}
+		r.onBranch(i+1, len(branches), r.snapshot())
+	}
+}
+
+// parallelConvolutionMinM is the smallest modulus for which simple shares each step among the workers.
+// Below it a step is too short to be worth the synchronization
+const parallelConvolutionMinM = 1 << 14
+
+// simpleInParallel is simple with the m entries of each step split into one contiguous block per worker.
+// Every entry of the next distribution only reads the previous distribution, so the blocks are independent,
+// and an entry near the start of a block may read the previous distribution from any other block where the
+// cyclic shift wraps around. The workers wait for each other after every element, and each entry is the same
+// single addition as in simple, so the result is identical
+func simpleInParallel(n, m, workers int) *Result {
+	if workers > m {
+		workers = m
+	}
+	prev := make([]*big.Int, m)
+	next := make([]*big.Int, m)
+	for i := range prev {
+		prev[i] = new(big.Int)
+		next[i] = new(big.Int)
+	}
+	prev[0].SetInt64(1)
+
+	block := (m + workers - 1) / workers
+	shifts := make([]chan int, workers)
+	var wg sync.WaitGroup
+	for w := range shifts {
+		lo, hi := w*block, min((w+1)*block, m)
+		shifts[w] = make(chan int)
+		go func(shifts <-chan int) {
+			for shift := range shifts {
+				for k := lo; k < hi; k++ {
+					col := k - shift
+					if col < 0 {
+						col += m
+					}
+					next[k].Add(prev[k], prev[col])
+				}
+				wg.Done()
+			}
+		}(shifts[w])
+	}
+
+	for i := 1; i <= n; i++ {
+		wg.Add(workers)
+		for _, s := range shifts {
+			s <- i % m
+		}
+		wg.Wait()
+		prev, next = next, prev
+	}
+	for _, s := range shifts {
+		close(s)
+	}
+
+	if err := checkNonNegative(prev); err != nil {
+		panic(err)
+	}
+	return &Result{N: n, M: m, Totals: prev}
+}
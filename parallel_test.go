@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+// TestRecursionSameForAnyWorkers checks that one worker, which runs doNextLevel directly, and several, which share
+// the branches of computeTotalsInParallel, give the same distribution
+func TestRecursionSameForAnyWorkers(t *testing.T) {
+	defer recursionWorkers.Store(recursionWorkers.Load())
+	for _, tc := range []struct{ n, m int }{{2000, 5}, {300, 7}, {40, 8}, {5, 12}, {9, 2}, {1, 1}} {
+		var want *Result
+		for _, workers := range []int{1, 2, 4, 8} {
+			recursionWorkers.Store(int64(workers))
+			got := recursion(tc.n, tc.m)
+			if want == nil {
+				want = got
+				continue
+			}
+			if !sameCounts(got.Totals, want.Totals) {
+				t.Errorf("n = %d, m = %d: %d workers gave %v, 1 worker %v", tc.n, tc.m, workers, got.Totals, want.Totals)
+			}
+		}
+		if exact := ResidueDistribution(tc.n, tc.m).Totals; !sameCounts(want.Totals, exact) {
+			t.Errorf("n = %d, m = %d: recursion gave %v, want %v", tc.n, tc.m, want.Totals, exact)
+		}
+	}
+}
+
+// TestSimpleInParallelMatchesSerial compares the blocked convolution with the serial one, including more workers
+// than residues and blocks where the cyclic shift wraps around. Run it with -race to check the blocks for data races
+func TestSimpleInParallelMatchesSerial(t *testing.T) {
+	defer recursionWorkers.Store(recursionWorkers.Load())
+	recursionWorkers.Store(1)
+	for _, tc := range []struct{ n, m, workers int }{
+		{2000, 5, 2},
+		{100, 5, 8},
+		{50, 7, 3},
+		{300, 1000, 4},
+		{1500, 1000, 7},
+		{40, parallelConvolutionMinM, 4},
+		{0, 3, 2},
+		{10, 1, 4},
+	} {
+		want := simple(tc.n, tc.m)
+		if got := simpleInParallel(tc.n, tc.m, tc.workers); !sameCounts(got.Totals, want.Totals) {
+			t.Errorf("n = %d, m = %d, %d workers: %v, want %v", tc.n, tc.m, tc.workers, got.Totals, want.Totals)
+		}
+	}
+}
+
+// BenchmarkSimpleInParallel times the convolution for a modulus in the hundreds of thousands, serially and
+// with the steps split among more and more workers
+func BenchmarkSimpleInParallel(b *testing.B) {
+	const n, m = 200, 200000
+	b.Run("serial", func(b *testing.B) {
+		defer recursionWorkers.Store(recursionWorkers.Load())
+		recursionWorkers.Store(1)
+		for i := 0; i < b.N; i++ {
+			simple(n, m)
+		}
+	})
+	for _, workers := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				simpleInParallel(n, m, workers)
+			}
+		})
+	}
+}
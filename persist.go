@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/hex"
+	"math/big"
+	"os"
+)
+
+// resultWire is the gob representation of a Result, with each count in its big.Int gob encoding
+type resultWire struct {
+	N      int
+	M      int
+	Totals [][]byte
+}
+
+// GobEncode implements gob.GobEncoder. The encoding only depends on the contents of r
+func (r *Result) GobEncode() ([]byte, error) {
+	wire := resultWire{N: r.N, M: r.M, Totals: make([][]byte, len(r.Totals))}
+	for i, t := range r.Totals {
+		b, err := t.GobEncode()
+		if err != nil {
+			return nil, err
+		}
+		wire.Totals[i] = b
+	}
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(wire); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// GobDecode implements gob.GobDecoder
+func (r *Result) GobDecode(data []byte) error {
+	var wire resultWire
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&wire); err != nil {
+		return err
+	}
+	totals := make([]*big.Int, len(wire.Totals))
+	for i, b := range wire.Totals {
+		totals[i] = new(big.Int)
+		if err := totals[i].GobDecode(b); err != nil {
+			return err
+		}
+	}
+	r.N, r.M, r.Totals = wire.N, wire.M, totals
+	return nil
+}
+
+// Hash returns a SHA-256 of N, M and the counts as a hex string, so results can be compared and used as cache keys
+// without comparing every count. Each value is written with its length first, so different results can only
+// collide if SHA-256 does. Unlike DistributionsEqual, results for different N have different hashes
+func (r *Result) Hash() string {
+	h := sha256.New()
+	var buf [8]byte
+	writeInt := func(x int) {
+		binary.BigEndian.PutUint64(buf[:], uint64(x))
+		h.Write(buf[:])
+	}
+	writeInt(r.N)
+	writeInt(r.M)
+	writeInt(len(r.Totals))
+	for _, t := range r.Totals {
+		b := t.Bytes()
+		// counts are never negative, but the sign is included so the hash is defined for any Result
+		writeInt(t.Sign())
+		writeInt(len(b))
+		h.Write(b)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// SaveResult writes r to the file at path, so an expensive result can be loaded later with LoadResult
+func SaveResult(path string, r *Result) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := gob.NewEncoder(f).Encode(r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// LoadResult reads a result written by SaveResult
+func LoadResult(path string) (*Result, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := &Result{}
+	if err := gob.NewDecoder(f).Decode(r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"path/filepath"
+	"testing"
+)
+
+func TestSaveLoadResultRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	for _, r := range []*Result{
+		ResidueDistribution(0, 1),
+		ResidueDistribution(2000, 5),
+		ResidueDistribution(100, 17),
+		{N: 3, M: 2, Totals: []*big.Int{big.NewInt(0), big.NewInt(-4)}},
+	} {
+		path := filepath.Join(dir, "result.gob")
+		if err := SaveResult(path, r); err != nil {
+			t.Fatal(err)
+		}
+		got, err := LoadResult(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.N != r.N || !DistributionsEqual(*got, *r) {
+			t.Errorf("loaded %+v, saved %+v", got, r)
+		}
+
+		// the encoding only depends on the result, so encoding it again or encoding a copy gives the same bytes
+		a, err := r.GobEncode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := r.Clone().GobEncode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(a, b) {
+			t.Errorf("n = %d, m = %d: a copy encodes differently", r.N, r.M)
+		}
+	}
+
+	if _, err := LoadResult(filepath.Join(dir, "missing.gob")); err == nil {
+		t.Error("loading a missing file succeeded")
+	}
+}
+
+func TestResultHash(t *testing.T) {
+	a := ResidueDistribution(2000, 5)
+	if a.Hash() != a.Clone().Hash() {
+		t.Error("a result and its copy hash differently")
+	}
+	if a.Hash() != recursion(2000, 5).Hash() {
+		t.Error("the same distribution computed another way hashes differently")
+	}
+
+	seen := map[string]string{}
+	for _, r := range []*Result{
+		a,
+		ResidueDistribution(1999, 5),
+		ResidueDistribution(2000, 4),
+		// the same counts as {1,...,4} modulo 2 but for another n
+		{N: 5, M: 2, Totals: ResidueDistribution(4, 2).Totals},
+		ResidueDistribution(4, 2),
+		{N: 4, M: 2, Totals: []*big.Int{big.NewInt(8), big.NewInt(-8)}},
+		// the bytes of the counts run together the same way without their lengths
+		{N: 1, M: 2, Totals: []*big.Int{big.NewInt(0x0102), big.NewInt(0x03)}},
+		{N: 1, M: 2, Totals: []*big.Int{big.NewInt(0x01), big.NewInt(0x0203)}},
+	} {
+		desc := fmt.Sprintf("N = %d, M = %d, %v", r.N, r.M, r.Totals)
+		h := r.Hash()
+		if other, ok := seen[h]; ok {
+			t.Errorf("%s and %s have the same hash", desc, other)
+		}
+		seen[h] = desc
+	}
+}
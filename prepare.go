@@ -0,0 +1,27 @@
+package main
+
+import "math/big"
+
+// Prepared holds the complete distribution for one (n, m), so that counts for any residue can be
+// read off cheaply. A server can prepare the common problems once at startup
+type Prepared struct {
+	result *Result
+}
+
+// Prepare does all the expensive work for the subsets of {1,...,n} modulo m, using the backend
+// chooseBackend picks for the problem
+func Prepare(n, m int) (*Prepared, error) {
+	if err := validateModulus(m); err != nil {
+		return nil, err
+	}
+	if err := validateN(n); err != nil {
+		return nil, err
+	}
+	return &Prepared{result: chooseBackend(n, m)(n, m)}, nil
+}
+
+// Count returns the number of subsets whose sum is r modulo m
+func (p *Prepared) Count(r int) *big.Int {
+	checkResidue(r, p.result.M)
+	return new(big.Int).Set(p.result.Totals[r])
+}
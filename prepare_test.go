@@ -0,0 +1,28 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPrepareMatchesOneShot(t *testing.T) {
+	for _, tc := range []struct{ n, m int }{{0, 1}, {2000, 5}, {100, 7}, {70000, 5}, {15, 16}} {
+		p, err := Prepare(tc.n, tc.m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := ResidueDistribution(tc.n, tc.m).Totals
+		for r := 0; r < tc.m; r++ {
+			if got := p.Count(r); got.Cmp(want[r]) != 0 {
+				t.Errorf("n = %d, m = %d: Count(%d) = %v, want %v", tc.n, tc.m, r, got, want[r])
+			}
+		}
+	}
+
+	if _, err := Prepare(10, 0); !errors.Is(err, ErrInvalidModulus) {
+		t.Errorf("Prepare(10, 0) = %v, want ErrInvalidModulus", err)
+	}
+	if _, err := Prepare(-1, 5); !errors.Is(err, ErrNegativeN) {
+		t.Errorf("Prepare(-1, 5) = %v, want ErrNegativeN", err)
+	}
+}
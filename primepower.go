@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// CountDivisibleByPrimePower counts the subsets of {1,...,n} whose sum is divisible by p^e.
+//
+// This is the character sum of rootsOfUnity for the residue 0, with the filtration
+// Z/p^eZ > pZ/p^eZ > ... > p^eZ/p^eZ = 0 making it cheap. The count is 1/p^e times the sum over the p^e-th
+// roots of unity w of (1 + w)(1 + w^2)...(1 + w^n). Group the roots by their order p^j: the products over
+// one period of p^j factors are 2 for j = 0 and 0 otherwise, so only the levels with p^j > n, or p odd, survive,
+// and the leftover factors expand to the distribution D of {1,...,n mod p^j} modulo p^j. The sum of w^k over
+// the primitive p^j-th roots only depends on which step of the filtration k falls in: it is p^j - p^(j-1) for
+// the multiples of p^j, -p^(j-1) for the other multiples of p^(j-1) and 0 for the rest. So each level costs
+// one distribution modulo p^j of fewer than p^j elements, instead of n convolution steps modulo p^e
+func CountDivisibleByPrimePower(n, p, e int) *big.Int {
+	if p < 2 || !big.NewInt(int64(p)).ProbablyPrime(0) {
+		panic(fmt.Errorf("%w: %d is not prime", ErrInvalidModulus, p))
+	}
+	if e < 1 {
+		panic(fmt.Errorf("%w: exponent must be at least 1, got %d", ErrInvalidModulus, e))
+	}
+	checkN(n)
+
+	total := new(big.Int)
+	term := new(big.Int)
+	for j, q := 0, 1; j <= e; j, q = j+1, q*p {
+		full, r := n/q, n%q
+
+		// the product over the complete periods is 2^full for odd q, and 0 for even q unless there are none
+		even := p == 2 && j > 0
+		if even && full > 0 {
+			continue
+		}
+		scale := big.NewInt(1)
+		if !even {
+			scale.Lsh(scale, uint(full))
+		}
+
+		// sum D[k] over the filtration steps: k divisible by p^j, or by p^(j-1) but not p^j
+		top, next := new(big.Int), new(big.Int)
+		for k, dk := range ResidueDistribution(r, q).Totals {
+			switch {
+			case k == 0:
+				top.Add(top, dk)
+			case j > 0 && k%(q/p) == 0:
+				next.Add(next, dk)
+			}
+		}
+		if j > 0 {
+			// c = (p^j - p^(j-1)) * top - p^(j-1) * next
+			top.Mul(top, big.NewInt(int64(q-q/p)))
+			top.Sub(top, term.Mul(next, big.NewInt(int64(q/p))))
+		}
+		total.Add(total, term.Mul(scale, top))
+	}
+
+	// the sum is p^e times the count
+	m := new(big.Int).Exp(big.NewInt(int64(p)), big.NewInt(int64(e)), nil)
+	count, rem := new(big.Int).QuoRem(total, m, new(big.Int))
+	if rem.Sign() != 0 || count.Sign() < 0 {
+		panic(fmt.Errorf("%w: character sum %v is not a nonnegative multiple of %v", ErrInternalCheckFailed, total, m))
+	}
+	return count
+}
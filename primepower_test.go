@@ -0,0 +1,58 @@
+package main
+
+import "testing"
+
+func TestCountDivisibleByPrimePowerMatchesRecursion(t *testing.T) {
+	for _, tc := range []struct{ p, e int }{{2, 3}, {3, 2}} {
+		m := 1
+		for k := 0; k < tc.e; k++ {
+			m *= tc.p
+		}
+		for _, n := range []int{0, 1, 2, 3, 5, 8, 9, 10, 17, 26, 27, 50} {
+			got := CountDivisibleByPrimePower(n, tc.p, tc.e)
+			if want := recursion(n, m).Totals[0]; got.Cmp(want) != 0 {
+				t.Errorf("CountDivisibleByPrimePower(%d, %d, %d) = %v, want %v", n, tc.p, tc.e, got, want)
+			}
+		}
+	}
+}
+
+func TestCountDivisibleByPrimePowerMatchesConvolution(t *testing.T) {
+	for _, tc := range []struct{ n, p, e int }{{0, 2, 1}, {1, 2, 1}, {1000, 2, 10}, {100, 2, 7}, {2000, 3, 5}, {37, 5, 3}, {500, 7, 2}, {12, 13, 1}} {
+		m := 1
+		for k := 0; k < tc.e; k++ {
+			m *= tc.p
+		}
+		got := CountDivisibleByPrimePower(tc.n, tc.p, tc.e)
+		if want := ResidueDistribution(tc.n, m).Totals[0]; got.Cmp(want) != 0 {
+			t.Errorf("CountDivisibleByPrimePower(%d, %d, %d) = %v, want %v", tc.n, tc.p, tc.e, got, want)
+		}
+	}
+}
+
+func TestCountDivisibleByPrimePowerRejectsComposite(t *testing.T) {
+	for _, tc := range []struct{ p, e int }{{4, 2}, {1, 3}, {0, 1}, {2, 0}} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("CountDivisibleByPrimePower(10, %d, %d) did not panic", tc.p, tc.e)
+				}
+			}()
+			CountDivisibleByPrimePower(10, tc.p, tc.e)
+		}()
+	}
+}
+
+// BenchmarkCountDivisibleByPrimePower compares the filtration with the convolution for m = 2^10
+func BenchmarkCountDivisibleByPrimePower(b *testing.B) {
+	b.Run("filtration", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			CountDivisibleByPrimePower(20000, 2, 10)
+		}
+	})
+	b.Run("convolution", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			ResidueDistribution(20000, 1024)
+		}
+	})
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// The protobuf wire format of the Result message in result.proto is written and read by hand here, so no
+// generated code or protobuf library is needed. A protobuf varint is the same as Go's unsigned varint
+
+// protobuf field numbers and wire types of the Result message
+const (
+	protoFieldN      = 1
+	protoFieldM      = 2
+	protoFieldTotals = 3
+	protoFieldGrand  = 4
+
+	protoVarint  = 0
+	protoFixed64 = 1
+	protoBytes   = 2
+	protoFixed32 = 5
+)
+
+var errBadProto = errors.New("malformed Result protobuf")
+
+// MarshalProto encodes r as a Result protobuf message
+func (r *Result) MarshalProto() ([]byte, error) {
+	var b []byte
+	appendVarint := func(field int, v uint64) {
+		b = binary.AppendUvarint(b, uint64(field<<3|protoVarint))
+		b = binary.AppendUvarint(b, v)
+	}
+	appendBigInt := func(field int, x *big.Int) error {
+		enc, err := x.GobEncode()
+		if err != nil {
+			return err
+		}
+		b = binary.AppendUvarint(b, uint64(field<<3|protoBytes))
+		b = binary.AppendUvarint(b, uint64(len(enc)))
+		b = append(b, enc...)
+		return nil
+	}
+
+	// proto3 leaves out scalar fields with the default value 0
+	if r.N != 0 {
+		appendVarint(protoFieldN, uint64(int64(r.N)))
+	}
+	if r.M != 0 {
+		appendVarint(protoFieldM, uint64(int64(r.M)))
+	}
+	grand := new(big.Int)
+	for _, t := range r.Totals {
+		if err := appendBigInt(protoFieldTotals, t); err != nil {
+			return nil, err
+		}
+		grand.Add(grand, t)
+	}
+	if err := appendBigInt(protoFieldGrand, grand); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// UnmarshalProto decodes a Result protobuf message into r. Unknown fields are skipped, and if the message
+// has a grand total it must match the sum of the totals
+func (r *Result) UnmarshalProto(data []byte) error {
+	var n, m int64
+	var totals []*big.Int
+	var grand *big.Int
+
+	for len(data) > 0 {
+		key, k := binary.Uvarint(data)
+		if k <= 0 {
+			return fmt.Errorf("%w: bad field key", errBadProto)
+		}
+		data = data[k:]
+		field, wire := int(key>>3), int(key&7)
+
+		switch wire {
+		case protoVarint:
+			v, k := binary.Uvarint(data)
+			if k <= 0 {
+				return fmt.Errorf("%w: bad varint in field %d", errBadProto, field)
+			}
+			data = data[k:]
+			switch field {
+			case protoFieldN:
+				n = int64(v)
+			case protoFieldM:
+				m = int64(v)
+			}
+		case protoBytes:
+			length, k := binary.Uvarint(data)
+			if k <= 0 || length > uint64(len(data)-k) {
+				return fmt.Errorf("%w: bad length in field %d", errBadProto, field)
+			}
+			value := data[k : k+int(length)]
+			data = data[k+int(length):]
+			if field != protoFieldTotals && field != protoFieldGrand {
+				continue
+			}
+			x := new(big.Int)
+			if err := x.GobDecode(value); err != nil {
+				return fmt.Errorf("%w: field %d: %v", errBadProto, field, err)
+			}
+			if field == protoFieldTotals {
+				totals = append(totals, x)
+			} else {
+				grand = x
+			}
+		case protoFixed64, protoFixed32:
+			size := 8
+			if wire == protoFixed32 {
+				size = 4
+			}
+			if len(data) < size {
+				return fmt.Errorf("%w: truncated field %d", errBadProto, field)
+			}
+			data = data[size:]
+		default:
+			return fmt.Errorf("%w: unsupported wire type %d", errBadProto, wire)
+		}
+	}
+
+	if grand != nil {
+		sum := new(big.Int)
+		for _, t := range totals {
+			sum.Add(sum, t)
+		}
+		if sum.Cmp(grand) != 0 {
+			return fmt.Errorf("%w: the totals do not add up to the grand total", errBadProto)
+		}
+	}
+	r.N, r.M, r.Totals = int(n), int(m), totals
+	return nil
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func TestResultProtoRoundTrip(t *testing.T) {
+	for _, r := range []*Result{
+		ResidueDistribution(0, 1),
+		ResidueDistribution(2000, 5),
+		ResidueDistribution(100, 17),
+		{N: 0, M: 0, Totals: nil},
+		{N: 3, M: 2, Totals: []*big.Int{big.NewInt(0), big.NewInt(-4)}},
+	} {
+		data, err := r.MarshalProto()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got Result
+		if err := got.UnmarshalProto(data); err != nil {
+			t.Fatalf("n = %d, m = %d: %v", r.N, r.M, err)
+		}
+		if got.N != r.N || got.M != r.M || !sameCounts(got.Totals, r.Totals) {
+			t.Errorf("decoded %+v, encoded %+v", got, *r)
+		}
+	}
+}
+
+func TestResultProtoSkipsUnknownFields(t *testing.T) {
+	r := ResidueDistribution(20, 5)
+	data, err := r.MarshalProto()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a varint field 9, a fixed32 field 10, a fixed64 field 11 and a bytes field 12, which a newer version
+	// might add
+	data = binary.AppendUvarint(data, 9<<3|protoVarint)
+	data = binary.AppendUvarint(data, 300)
+	data = binary.AppendUvarint(data, 10<<3|protoFixed32)
+	data = append(data, 1, 2, 3, 4)
+	data = binary.AppendUvarint(data, 11<<3|protoFixed64)
+	data = append(data, 1, 2, 3, 4, 5, 6, 7, 8)
+	data = binary.AppendUvarint(data, 12<<3|protoBytes)
+	data = append(data, 2, 'h', 'i')
+
+	var got Result
+	if err := got.UnmarshalProto(data); err != nil {
+		t.Fatal(err)
+	}
+	if !DistributionsEqual(got, *r) || got.N != r.N {
+		t.Errorf("decoded %+v, want %+v", got, *r)
+	}
+}
+
+func TestResultProtoRejectsMalformed(t *testing.T) {
+	data, err := ResidueDistribution(20, 5).MarshalProto()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a total that does not add up to the grand total
+	bad := &Result{N: 2, M: 2, Totals: []*big.Int{big.NewInt(2), big.NewInt(2)}}
+	extra, err := bad.MarshalProto()
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc, _ := big.NewInt(1).GobEncode()
+	extra = binary.AppendUvarint(extra, protoFieldTotals<<3|protoBytes)
+	extra = binary.AppendUvarint(extra, uint64(len(enc)))
+	extra = append(extra, enc...)
+
+	for name, data := range map[string][]byte{
+		"truncated":      data[:len(data)-1],
+		"bad key":        {0x80},
+		"bad wire type":  {protoFieldN<<3 | 3},
+		"bad length":     {protoFieldTotals<<3 | protoBytes, 100, 1},
+		"short fixed32":  {10<<3 | protoFixed32, 1, 2},
+		"wrong grand":    extra,
+		"bad big.Int":    {protoFieldTotals<<3 | protoBytes, 1, 0xff},
+		"unended varint": {protoFieldN << 3, 0x80},
+	} {
+		var r Result
+		if err := r.UnmarshalProto(data); !errors.Is(err, errBadProto) {
+			t.Errorf("%s: got %v, want errBadProto", name, err)
+		}
+	}
+}
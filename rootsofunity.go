@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// rootsOfUnity computes the distribution of subset sums of {1,...,n} modulo m from the character sums
+//
+//	totals[t] = 1/m * sum over j of w^(-jt) * (1 + w^j)(1 + w^2j)...(1 + w^nj)
+//
+// where w = e^(2 pi i/m). The j = 0 term is 2^n, which gives the near uniform main part 2^n/m, and the other
+// terms are the small correction. Group the j by the order q of w^j, so z = w^j is a primitive q-th root of unity.
+// Since z^q = 1 the product repeats every q factors, and over one period it is the product of (1 + z) over all
+// q-th roots z, which is 2 for odd q and 0 for even q. The leftover r = n mod q factors expand to
+// the sum of D[k] z^k, where D is the distribution of {1,...,r} modulo q. Summing over the primitive roots turns
+// z^(k-t) into the Ramanujan sum c_q(k - t), an ordinary integer, so everything stays exact and only needs
+// O(m^2) work per divisor of m instead of the full recursion or n convolution steps
+func rootsOfUnity(n, m int) *Result {
+	checkModulus(m)
+	checkN(n)
+
+	totals := make([]*big.Int, m)
+	for t := range totals {
+		totals[t] = new(big.Int)
+	}
+
+	sum := new(big.Int)
+	term := new(big.Int)
+	for q := 1; q <= m; q++ {
+		if m%q != 0 {
+			continue
+		}
+		full, r := n/q, n%q
+
+		// the product over the complete periods
+		if q%2 == 0 && full > 0 {
+			continue
+		}
+		scale := big.NewInt(1)
+		if q%2 == 1 {
+			scale.Lsh(scale, uint(full))
+		}
+
+		d := ResidueDistribution(r, q).Totals
+		for t := 0; t < m; t++ {
+			sum.SetInt64(0)
+			for k, dk := range d {
+				c := ramanujanSum(q, ((k-t)%q+q)%q)
+				sum.Add(sum, term.Mul(dk, big.NewInt(int64(c))))
+			}
+			totals[t].Add(totals[t], term.Mul(scale, sum))
+		}
+	}
+
+	// every entry is m times the count
+	rem := new(big.Int)
+	bm := big.NewInt(int64(m))
+	for t, total := range totals {
+		total.QuoRem(total, bm, rem)
+		if rem.Sign() != 0 {
+			panic(fmt.Errorf("%w: character sum for residue %d is not divisible by %d", ErrInternalCheckFailed, t, m))
+		}
+	}
+	if err := checkNonNegative(totals); err != nil {
+		panic(err)
+	}
+	return &Result{N: n, M: m, Totals: totals}
+}
+
+// ramanujanSum returns c_q(k), the sum of z^k over the primitive q-th roots of unity z,
+// which is the sum of mobius(q/d) * d over the divisors d of gcd(k, q)
+func ramanujanSum(q, k int) int {
+	g := gcd(k, q)
+	c := 0
+	for d := 1; d <= g; d++ {
+		if g%d == 0 {
+			c += mobius(q/d) * d
+		}
+	}
+	return c
+}
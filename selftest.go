@@ -0,0 +1,60 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"math/big"
+)
+
+// runSelftest implements the selftest subcommand. It checks that the binomial coefficients of every
+// length up to -length add up to 2^length, and that the distributions for a spread of (n, m) account for
+// all 2^n subsets. It prints PASS or FAIL for each check and reports whether they all passed
+func runSelftest(w io.Writer, args []string) bool {
+	fs := flag.NewFlagSet("selftest", flag.ExitOnError)
+	length := fs.Int("length", 400, "check the binomial coefficients for lengths 0 to this")
+	fs.Parse(args)
+
+	passed := true
+	report := func(name string, check func() error) {
+		err := func() (err error) {
+			// the computations panic when their internal checks fail
+			defer func() {
+				if p := recover(); p != nil {
+					err = fmt.Errorf("%v", p)
+				}
+			}()
+			return check()
+		}()
+		if err != nil {
+			passed = false
+			fmt.Fprintln(w, "FAIL", name+":", err)
+			return
+		}
+		fmt.Fprintln(w, "PASS", name)
+	}
+
+	for l := 0; l <= *length; l++ {
+		report(fmt.Sprintf("binomial sum for length %d", l), func() error {
+			b := &binomial{}
+			b.populate(l)
+			return nil
+		})
+	}
+
+	for _, n := range []int{0, 1, 2, 7, 50, 100, 999, 2000} {
+		for _, m := range []int{1, 2, 3, 5, 10, 17, 64} {
+			report(fmt.Sprintf("grand total for n=%d m=%d", n, m), func() error {
+				sum := new(big.Int)
+				for _, t := range ResidueDistribution(n, m).Totals {
+					sum.Add(sum, t)
+				}
+				if !isPowerOfTwo(sum, n) {
+					return fmt.Errorf("%w: the counts add up to %v", ErrInternalCheckFailed, sum)
+				}
+				return nil
+			})
+		}
+	}
+	return passed
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// sizeResidueTable returns table[k][r], the number of subsets of {1,...,n} with k elements whose sum is r modulo m,
+// for the sizes k = 0 ... maxSize. Like simple(), each element i is added in turn, and a subset of size k with i
+// comes from a subset of size k-1 without it, shifted by i
+func sizeResidueTable(n, m, maxSize int) [][]*big.Int {
+	table := make([][]*big.Int, maxSize+1)
+	for k := range table {
+		table[k] = make([]*big.Int, m)
+		for r := range table[k] {
+			table[k][r] = new(big.Int)
+		}
+	}
+	table[0][0].SetInt64(1)
+
+	shifted := make([]*big.Int, m)
+	for r := range shifted {
+		shifted[r] = new(big.Int)
+	}
+	for i := 1; i <= n; i++ {
+		shift := i % m
+		// work downwards in k so each subset only uses i once
+		top := i
+		if top > maxSize {
+			top = maxSize
+		}
+		for k := top; k >= 1; k-- {
+			for r, t := range table[k-1] {
+				shifted[(r+shift)%m].Set(t)
+			}
+			for r, t := range shifted {
+				table[k][r].Add(table[k][r], t)
+			}
+		}
+	}
+	return table
+}
+
+// CountDivisibleSizeRange counts the subsets of {1,...,n} with at least lo and at most hi elements
+// whose sum is divisible by m. 0 <= lo <= hi <= n is required
+func CountDivisibleSizeRange(n, m, lo, hi int) *big.Int {
+	checkModulus(m)
+	checkN(n)
+	if lo < 0 || lo > hi || hi > n {
+		panic(fmt.Errorf("%w: size range [%d, %d] for n = %d", ErrSizeOutOfRange, lo, hi, n))
+	}
+
+	table := sizeResidueTable(n, m, hi)
+	count := new(big.Int)
+	for k := lo; k <= hi; k++ {
+		count.Add(count, table[k][0])
+	}
+
+	// Check the counts against the binomial coefficients: there are C(n, k) subsets of size k
+	for k, b := range Binomials(n)[:hi+1] {
+		sum := new(big.Int)
+		for _, t := range table[k] {
+			sum.Add(sum, t)
+		}
+		if sum.Cmp(b) != 0 {
+			panic(fmt.Errorf("%w: bad total for size %d", ErrInternalCheckFailed, k))
+		}
+	}
+	return count
+}
+
+// MostLikelySizeForDivisible returns the most common size among the subsets of {1,...,n} whose sum is divisible
+// by m, with the number of such subsets of that size. Ties go to the smallest size
+func MostLikelySizeForDivisible(n, m int) (size int, count *big.Int) {
+	checkModulus(m)
+	checkN(n)
+	table := sizeResidueTable(n, m, n)
+	size, count = 0, table[0][0]
+	for k, row := range table {
+		if row[0].Cmp(count) > 0 {
+			size, count = k, row[0]
+		}
+	}
+	return size, new(big.Int).Set(count)
+}
+
+// The table of subsets by size and exact sum has n+1 rows of n(n+1)/2 + 1 entries, and adding each element
+// updates all of it, so the time grows as n^4. Beyond this limit it is no longer practical
+const maxIntegerAverageN = 100
+
+// CountIntegerAverageDivisible counts the nonempty subsets of {1,...,n} whose average, the sum divided by the
+// size, is an integer divisible by m. That is a sum divisible by m times the size, which is not a condition on
+// the sum modulo a fixed modulus, so it needs the exact sums: table[k][s] is the number of subsets with k elements
+// and sum s, built like FullSumDistribution with the size tracked as well. n must not exceed maxIntegerAverageN
+func CountIntegerAverageDivisible(n, m int) *big.Int {
+	checkModulus(m)
+	checkN(n)
+	if n > maxIntegerAverageN {
+		panic(fmt.Errorf("%w: %d exceeds the integer average limit of %d", ErrNTooLarge, n, maxIntegerAverageN))
+	}
+
+	maxSum := n * (n + 1) / 2
+	table := make([][]*big.Int, n+1)
+	for k := range table {
+		table[k] = make([]*big.Int, maxSum+1)
+		for s := range table[k] {
+			table[k][s] = new(big.Int)
+		}
+	}
+	table[0][0].SetInt64(1)
+
+	// add element i, working downwards in k so each subset only uses it once
+	top := 0
+	for i := 1; i <= n; i++ {
+		top += i
+		for k := i; k >= 1; k-- {
+			for s := top; s >= i; s-- {
+				table[k][s].Add(table[k][s], table[k-1][s-i])
+			}
+		}
+	}
+
+	count := new(big.Int)
+	for k := 1; k <= n; k++ {
+		for s := 0; s <= maxSum; s += k * m {
+			count.Add(count, table[k][s])
+		}
+	}
+	return count
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func TestCountDivisibleSizeRangeBruteForce(t *testing.T) {
+	for n := 0; n <= 10; n++ {
+		for m := 1; m <= 6; m++ {
+			for lo := 0; lo <= n; lo++ {
+				for hi := lo; hi <= n; hi++ {
+					want := countBruteForce(oneTo(n), func(subset []int) bool {
+						return len(subset) >= lo && len(subset) <= hi && sumOf(subset)%m == 0
+					})
+					if got := CountDivisibleSizeRange(n, m, lo, hi); got.Cmp(want) != 0 {
+						t.Errorf("CountDivisibleSizeRange(%d, %d, %d, %d) = %v, want %v", n, m, lo, hi, got, want)
+					}
+				}
+			}
+		}
+	}
+
+	// the whole range is every subset
+	if got, want := CountDivisibleSizeRange(300, 7, 0, 300), ResidueDistribution(300, 7).Totals[0]; got.Cmp(want) != 0 {
+		t.Errorf("CountDivisibleSizeRange(300, 7, 0, 300) = %v, want %v", got, want)
+	}
+
+	for _, r := range [][2]int{{-1, 3}, {4, 3}, {0, 11}} {
+		if err := panicError(func() { CountDivisibleSizeRange(10, 5, r[0], r[1]) }); !errors.Is(err, ErrSizeOutOfRange) {
+			t.Errorf("size range %v gave %v, want ErrSizeOutOfRange", r, err)
+		}
+	}
+}
+
+func TestMostLikelySizeForDivisibleBruteForce(t *testing.T) {
+	for n := 0; n <= 12; n++ {
+		for m := 1; m <= 7; m++ {
+			bySize := make([]int64, n+1)
+			forEachSubset(oneTo(n), func(subset []int) {
+				if sumOf(subset)%m == 0 {
+					bySize[len(subset)]++
+				}
+			})
+			// ties go to the smallest size
+			wantSize := 0
+			for k, c := range bySize {
+				if c > bySize[wantSize] {
+					wantSize = k
+				}
+			}
+			size, count := MostLikelySizeForDivisible(n, m)
+			if size != wantSize || count.Cmp(big.NewInt(bySize[wantSize])) != 0 {
+				t.Errorf("MostLikelySizeForDivisible(%d, %d) = %d, %v, want %d, %d", n, m, size, count, wantSize, bySize[wantSize])
+			}
+		}
+	}
+}
+
+func TestCountIntegerAverageDivisibleBruteForce(t *testing.T) {
+	for n := 0; n <= 12; n++ {
+		for m := 1; m <= 5; m++ {
+			want := countBruteForce(oneTo(n), func(subset []int) bool {
+				k := len(subset)
+				return k > 0 && sumOf(subset)%k == 0 && sumOf(subset)/k%m == 0
+			})
+			if got := CountIntegerAverageDivisible(n, m); got.Cmp(want) != 0 {
+				t.Errorf("CountIntegerAverageDivisible(%d, %d) = %v, want %v", n, m, got, want)
+			}
+		}
+	}
+	if err := panicError(func() { CountIntegerAverageDivisible(maxIntegerAverageN+1, 3) }); !errors.Is(err, ErrNTooLarge) {
+		t.Errorf("n beyond the limit gave %v, want ErrNTooLarge", err)
+	}
+}
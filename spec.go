@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// Spec fully describes a counting problem: the universe has ResidueCounts[v] elements congruent
+// to v modulo Modulus, and the question is how many of its subsets have a sum congruent to Target.
+// Only the residues of the elements matter, so this covers ranges, arbitrary element lists and more
+type Spec struct {
+	ResidueCounts []int
+	Modulus       int
+	Target        int
+}
+
+// validate checks that the spec describes a problem that can be computed
+func (s Spec) validate() error {
+	if err := validateModulus(s.Modulus); err != nil {
+		return err
+	}
+	if len(s.ResidueCounts) != s.Modulus {
+		return fmt.Errorf("%w: expected %d residue counts for modulus %d, got %d",
+			ErrResidueCountLengthMismatch, s.Modulus, s.Modulus, len(s.ResidueCounts))
+	}
+	for v, c := range s.ResidueCounts {
+		if c < 0 {
+			return fmt.Errorf("%w: residue %d has count %d", ErrNegativeN, v, c)
+		}
+	}
+	if s.Target < 0 || s.Target >= s.Modulus {
+		return fmt.Errorf("%w: target %d is not in [0, %d)", ErrResidueOutOfRange, s.Target, s.Modulus)
+	}
+	return nil
+}
+
+// CountFromSpec returns the number of subsets of the universe described by spec whose sum is congruent to spec.Target.
+// This is the general entry point; RangeSpec and ElementsSpec build specs for the common universes
+func CountFromSpec(spec Spec) (*big.Int, error) {
+	if err := spec.validate(); err != nil {
+		return nil, err
+	}
+	return distributionFromCounts(spec.ResidueCounts)[spec.Target], nil
+}
+
+// ProductUniverseDistribution returns the distribution modulo the common modulus of the sums of the pairs of a subset of
+// a's universe and a subset of b's universe, chosen independently. The sums add, so this is the cyclic convolution
+// of the two distributions, as in CombineDistributions. The targets of the specs are not used
+func ProductUniverseDistribution(a, b Spec) []*big.Int {
+	for _, s := range []Spec{a, b} {
+		if err := s.validate(); err != nil {
+			panic(err)
+		}
+	}
+	if a.Modulus != b.Modulus {
+		panic(fmt.Errorf("%w: universes modulo %d and %d", ErrInvalidModulus, a.Modulus, b.Modulus))
+	}
+	return CombineDistributions(distributionFromCounts(a.ResidueCounts), distributionFromCounts(b.ResidueCounts), a.Modulus)
+}
+
+// CountFromResidueCounts returns the number of subsets with sum congruent to target modulo m of a universe with
+// counts[v] elements congruent to v. counts must have exactly m entries, otherwise the error wraps
+// ErrResidueCountLengthMismatch
+func CountFromResidueCounts(counts []int, m, target int) (*big.Int, error) {
+	return CountFromSpec(Spec{ResidueCounts: counts, Modulus: m, Target: target})
+}
+
+// RangeResidueCounts returns how many integers in {1,...,n} fall in each residue class modulo m.
+// When m divides n every class has n/m elements. Otherwise the partition is uneven: the classes
+// 1 ... n%m have one element more than the others, because the final partial row of the
+// columns 1 ... m only reaches as far as n
+func RangeResidueCounts(n, m int) []int {
+	checkModulus(m)
+	checkN(n)
+	counts := make([]int, m)
+	for v := range counts {
+		counts[v] = n / m
+		if v >= 1 && v <= n%m {
+			counts[v]++
+		}
+	}
+	return counts
+}
+
+// RangeSpec returns the spec for the subsets of {1,...,n} with sum congruent to target modulo m
+func RangeSpec(n, m, target int) Spec {
+	return Spec{ResidueCounts: RangeResidueCounts(n, m), Modulus: m, Target: target}
+}
+
+// ElementsSpec returns the spec for the subsets of the given elements with sum congruent to target modulo m.
+// Repeated values count as separate elements
+func ElementsSpec(elems []int, m, target int) Spec {
+	checkModulus(m)
+	counts := make([]int, m)
+	for _, e := range elems {
+		v := e % m
+		if v < 0 {
+			v += m
+		}
+		counts[v]++
+	}
+	return Spec{ResidueCounts: counts, Modulus: m, Target: target}
+}
+
+// CountForBigElements returns the distribution modulo m of the subset sums of elems, whose values may be too large
+// for an int. Only the residue of each element matters, and big.Int's Mod is always in [0, m), even for negative
+// values. Repeated values count as separate elements
+func CountForBigElements(elems []*big.Int, m int) []*big.Int {
+	checkModulus(m)
+	bm := big.NewInt(int64(m))
+	counts := make([]int, m)
+	v := new(big.Int)
+	for _, e := range elems {
+		counts[v.Mod(e, bm).Int64()]++
+	}
+	return distributionFromCounts(counts)
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+// specElements returns a list of elements with the residue counts of spec
+func specElements(spec Spec) []int {
+	var elems []int
+	for v, c := range spec.ResidueCounts {
+		for j := 0; j < c; j++ {
+			elems = append(elems, v+j*spec.Modulus)
+		}
+	}
+	return elems
+}
+
+func TestCountFromSpecBruteForce(t *testing.T) {
+	for _, spec := range []Spec{
+		RangeSpec(12, 5, 0),
+		RangeSpec(10, 4, 3),
+		RangeSpec(0, 3, 0),
+		ElementsSpec([]int{7, 7, -3, 100, 0, 5, 12}, 6, 2),
+		ElementsSpec([]int{1, 2, 4, 8, 16, 32, 64}, 7, 1),
+		{ResidueCounts: []int{0, 5, 0, 3, 2}, Modulus: 5, Target: 4},
+		{ResidueCounts: []int{2, 0, 0, 0, 0, 0, 0, 8}, Modulus: 8, Target: 0},
+		{ResidueCounts: []int{11}, Modulus: 1, Target: 0},
+	} {
+		elems := specElements(spec)
+		want := countBruteForce(elems, func(subset []int) bool {
+			return ((sumOf(subset)-spec.Target)%spec.Modulus+spec.Modulus)%spec.Modulus == 0
+		})
+		got, err := CountFromSpec(spec)
+		if err != nil {
+			t.Fatalf("%+v: %v", spec, err)
+		}
+		if got.Cmp(want) != 0 {
+			t.Errorf("CountFromSpec(%+v) = %v, want %v", spec, got, want)
+		}
+	}
+}
+
+func TestCountFromSpecValidates(t *testing.T) {
+	for _, tc := range []struct {
+		spec Spec
+		want error
+	}{
+		{Spec{ResidueCounts: nil, Modulus: 0}, ErrInvalidModulus},
+		{Spec{ResidueCounts: []int{1, 2}, Modulus: 3}, ErrResidueCountLengthMismatch},
+		{Spec{ResidueCounts: []int{1, -2, 3}, Modulus: 3}, ErrNegativeN},
+		{Spec{ResidueCounts: []int{1, 2, 3}, Modulus: 3, Target: 3}, ErrResidueOutOfRange},
+	} {
+		if _, err := CountFromSpec(tc.spec); !errors.Is(err, tc.want) {
+			t.Errorf("CountFromSpec(%+v) = %v, want an error wrapping %v", tc.spec, err, tc.want)
+		}
+	}
+}
+
+func TestRangeResidueCounts(t *testing.T) {
+	for _, tc := range []struct {
+		n, m int
+		want []int
+	}{
+		{0, 3, []int{0, 0, 0}},
+		{10, 5, []int{2, 2, 2, 2, 2}},
+		{12, 4, []int{3, 3, 3, 3}},
+		{2000, 5, []int{400, 400, 400, 400, 400}},
+		// uneven: the classes 1 ... n%m get one more
+		{11, 4, []int{2, 3, 3, 3}},
+		{2, 5, []int{0, 1, 1, 0, 0}},
+		{7, 1, []int{7}},
+	} {
+		if got := RangeResidueCounts(tc.n, tc.m); fmt.Sprint(got) != fmt.Sprint(tc.want) {
+			t.Errorf("RangeResidueCounts(%d, %d) = %v, want %v", tc.n, tc.m, got, tc.want)
+		}
+	}
+
+	for n := 0; n <= 30; n++ {
+		for m := 1; m <= 9; m++ {
+			want := make([]int, m)
+			for i := 1; i <= n; i++ {
+				want[i%m]++
+			}
+			if got := RangeResidueCounts(n, m); fmt.Sprint(got) != fmt.Sprint(want) {
+				t.Errorf("RangeResidueCounts(%d, %d) = %v, want %v", n, m, got, want)
+			}
+		}
+	}
+}
+
+func TestProductUniverseDistributionBruteForce(t *testing.T) {
+	for _, tc := range []struct{ a, b Spec }{
+		{RangeSpec(6, 5, 0), RangeSpec(5, 5, 0)},
+		{ElementsSpec([]int{3, 3, 9, -4}, 6, 0), RangeSpec(7, 6, 1)},
+		{Spec{ResidueCounts: []int{0, 0, 0, 0}, Modulus: 4}, ElementsSpec([]int{1, 5, 9, 2}, 4, 0)},
+		{RangeSpec(4, 1, 0), RangeSpec(8, 1, 0)},
+	} {
+		// the combined universe lists the elements of both, so a subset of it is a pair of subsets
+		elems := append(specElements(tc.a), specElements(tc.b)...)
+		want := bruteForceDistribution(elems, tc.a.Modulus)
+		if got := ProductUniverseDistribution(tc.a, tc.b); !sameCounts(got, want) {
+			t.Errorf("ProductUniverseDistribution(%+v, %+v) = %v, want %v", tc.a, tc.b, got, want)
+		}
+	}
+
+	for _, tc := range []struct {
+		a, b Spec
+		want error
+	}{
+		{RangeSpec(6, 5, 0), RangeSpec(6, 4, 0), ErrInvalidModulus},
+		{RangeSpec(6, 5, 0), Spec{ResidueCounts: []int{1}, Modulus: 5}, ErrResidueCountLengthMismatch},
+	} {
+		if err := panicError(func() { ProductUniverseDistribution(tc.a, tc.b) }); !errors.Is(err, tc.want) {
+			t.Errorf("ProductUniverseDistribution(%+v, %+v) gave %v, want %v", tc.a, tc.b, err, tc.want)
+		}
+	}
+}
+
+func TestCountFromResidueCountsLength(t *testing.T) {
+	for _, counts := range [][]int{nil, {1, 2, 3, 4}, {1, 2, 3, 4, 5, 6}} {
+		_, err := CountFromResidueCounts(counts, 5, 0)
+		if !errors.Is(err, ErrResidueCountLengthMismatch) {
+			t.Errorf("%d counts for modulus 5 gave %v, want ErrResidueCountLengthMismatch", len(counts), err)
+			continue
+		}
+		if want := fmt.Sprintf("expected 5 residue counts for modulus 5, got %d", len(counts)); !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q doesn't say %q", err, want)
+		}
+	}
+
+	got, err := CountFromResidueCounts(RangeResidueCounts(2000, 5), 5, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := ResidueDistribution(2000, 5).Totals[0]; got.Cmp(want) != 0 {
+		t.Errorf("CountFromResidueCounts for {1,...,2000} = %v, want %v", got, want)
+	}
+}
+
+func TestCountForBigElements(t *testing.T) {
+	maxInt := big.NewInt(math.MaxInt64)
+	huge, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	elems := []*big.Int{
+		maxInt,
+		new(big.Int).Sub(maxInt, big.NewInt(1)),
+		new(big.Int).Add(maxInt, big.NewInt(1)),
+		new(big.Int).Lsh(maxInt, 3),
+		new(big.Int).Neg(maxInt),
+		huge,
+		new(big.Int).Neg(huge),
+		big.NewInt(7),
+	}
+	for _, m := range []int{1, 2, 5, 7, 12} {
+		// the sums of every subset, in big.Int arithmetic so nothing overflows
+		want := make([]*big.Int, m)
+		for r := range want {
+			want[r] = new(big.Int)
+		}
+		bm := big.NewInt(int64(m))
+		for mask := 0; mask < 1<<len(elems); mask++ {
+			sum := new(big.Int)
+			for i, e := range elems {
+				if mask>>i&1 == 1 {
+					sum.Add(sum, e)
+				}
+			}
+			r := sum.Mod(sum, bm).Int64()
+			want[r].Add(want[r], big.NewInt(1))
+		}
+		if got := CountForBigElements(elems, m); !sameCounts(got, want) {
+			t.Errorf("m = %d: %v, want %v", m, got, want)
+		}
+	}
+
+	// small values give the same as the range
+	var small []*big.Int
+	for i := 1; i <= 100; i++ {
+		small = append(small, big.NewInt(int64(i)))
+	}
+	if got, want := CountForBigElements(small, 7), ResidueDistribution(100, 7).Totals; !sameCounts(got, want) {
+		t.Errorf("{1,...,100} modulo 7 gave %v, want %v", got, want)
+	}
+}
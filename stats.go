@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math/big"
+	"text/tabwriter"
+	"time"
+)
+
+// LevelStats describes one level of the recursion. Visits is the number of times the level was reached with
+// a nonzero accumulator, Branches the number of entries of the level's row it tried, of which Pruned gave a zero
+// accumulator and were not followed. Time includes the time spent in the deeper levels, so the time spent at
+// the level itself is the difference from the next level
+type LevelStats struct {
+	Visits   int64
+	Branches int64
+	Pruned   int64
+	Time     time.Duration
+}
+
+// RecursionStats has the LevelStats for each level 0 ... m-1 of one run of the recursion, and the number of
+// complete branches it added to the totals. Without pruning there would be m^m of those, but the row for
+// residue 0 only has one nonzero entry, so even when no other entry is zero there are at most m^(m-1)
+type RecursionStats struct {
+	Levels []LevelStats
+	Leaves int64
+}
+
+// recursionWithStats is recursion instrumented to collect RecursionStats. The instrumentation needs the
+// branches to run in order, so it always uses one goroutine, and timing every branch slows it down
+func recursionWithStats(n, m int) (*Result, *RecursionStats) {
+	stats := &RecursionStats{Levels: make([]LevelStats, m)}
+	r := &recurse{n: n, m: m, stats: stats}
+	r.initialize()
+
+	r.computeColumnModuloTotals()
+
+	r.computeTotalsRecursively()
+
+	return r.result(), stats
+}
+
+// print writes the statistics as a table, with the time spent at each level itself next to the total
+func (s *RecursionStats) print(w io.Writer) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "level\tvisits\tbranches\tpruned\ttime\tself\t")
+	for level, l := range s.Levels {
+		self := l.Time
+		if level+1 < len(s.Levels) {
+			self -= s.Levels[level+1].Time
+		}
+		fmt.Fprintf(tw, "%d\t%d\t%d\t%d\t%v\t%v\t\n", level, l.Visits, l.Branches, l.Pruned, l.Time.Round(time.Microsecond), self.Round(time.Microsecond))
+	}
+	tw.Flush()
+
+	m := int64(len(s.Levels))
+	all := new(big.Int).Exp(big.NewInt(m), big.NewInt(m), nil)
+	fraction, _ := new(big.Rat).SetFrac(big.NewInt(s.Leaves), all).Float64()
+	fmt.Fprintf(w, "%d leaves of %v without pruning (%.3g%%)\n", s.Leaves, all, 100*fraction)
+}
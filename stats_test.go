@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func TestRecursionStatsLeaves(t *testing.T) {
+	unpruned := 0
+	for _, tc := range []struct{ n, m int }{{0, 1}, {5, 1}, {3, 4}, {6, 5}, {10, 6}, {40, 4}, {60, 6}, {30, 3}, {50, 5}, {70, 7}} {
+		res, stats := recursionWithStats(tc.n, tc.m)
+		if !sameCounts(res.Totals, ResidueDistribution(tc.n, tc.m).Totals) {
+			t.Errorf("n = %d, m = %d: the instrumented recursion gave %v", tc.n, tc.m, res.Totals)
+		}
+
+		// a branch is pruned exactly when it picks a zero entry, so the leaves are the choices of one nonzero
+		// entry from each row
+		r := &recurse{n: tc.n, m: tc.m}
+		r.initialize()
+		r.computeColumnModuloTotals()
+		want := int64(1)
+		zeros := 0
+		for _, row := range r.sums {
+			nonzero := int64(0)
+			for _, s := range row {
+				if s.Sign() != 0 {
+					nonzero++
+				}
+			}
+			want *= nonzero
+			zeros += tc.m - int(nonzero)
+		}
+		if stats.Leaves != want {
+			t.Errorf("n = %d, m = %d: %d leaves, want %d", tc.n, tc.m, stats.Leaves, want)
+		}
+
+		// the row for residue 0 always has m - 1 zero entries, so with no others the m^m leaves of the
+		// unpruned tree, one for each choice of an entry from each row, come down to m^(m-1). The row for
+		// residue c only has nonzero entries at the multiples of gcd(c, m), so that takes a prime m and a large n
+		if zeros == tc.m-1 {
+			unpruned++
+			all := int64(1)
+			for i := 1; i < tc.m; i++ {
+				all *= int64(tc.m)
+			}
+			if stats.Leaves != all {
+				t.Errorf("n = %d, m = %d has no zero entries outside row 0 but %d leaves, want %d", tc.n, tc.m, stats.Leaves, all)
+			}
+		}
+
+		// each branch that isn't pruned visits the next level, and those of the last level end at a leaf
+		for level, l := range stats.Levels {
+			next := stats.Leaves
+			if level+1 < tc.m {
+				next = stats.Levels[level+1].Visits
+			}
+			if l.Branches-l.Pruned != next {
+				t.Errorf("n = %d, m = %d: level %d has %d branches and %d pruned, but %d reach the next level",
+					tc.n, tc.m, level, l.Branches, l.Pruned, next)
+			}
+		}
+
+		var out bytes.Buffer
+		stats.print(&out)
+		all := new(big.Int).Exp(big.NewInt(int64(tc.m)), big.NewInt(int64(tc.m)), nil)
+		if line := fmt.Sprintf("%d leaves of %v without pruning", want, all); !strings.Contains(out.String(), line) {
+			t.Errorf("n = %d, m = %d: the statistics don't say %q:\n%s", tc.n, tc.m, line, out.String())
+		}
+	}
+	if unpruned < 5 {
+		t.Errorf("only %d cases had no zero entries outside row 0", unpruned)
+	}
+}
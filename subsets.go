@@ -4,8 +4,17 @@ package main
 // such that the sum of their elements is divisible by 5
 
 import (
+	"context"
+	"flag"
 	"fmt"
+	"io"
+	"math"
 	"math/big"
+	"math/bits"
+	"os"
+	"os/signal"
+	"runtime"
+	"time"
 )
 
 // Think of the problem in terms of columns of elements whose value is the same modulo 5
@@ -53,11 +62,20 @@ const columns = 5
 const rows = 400
 
 type binomial struct {
-	vals [rows + 1]*big.Int
+	vals []*big.Int
 	sum  *big.Int
 }
 
-func (b *binomial) populate() {
+// isPowerOfTwo reports whether x is exactly 2^e, that is a 1 followed by e zero bits. Unlike comparing with
+// 2^e this needs no memory, so the grand total checks cost nothing extra even for huge e
+func isPowerOfTwo(x *big.Int, e int) bool {
+	return x.Sign() > 0 && x.BitLen() == e+1 && x.TrailingZeroBits() == uint(e)
+}
+
+// populate computes the binomial coefficients C(length, 0) ... C(length, length)
+func (b *binomial) populate(length int) {
+	b.vals = make([]*big.Int, length+1)
+
 	// compute each binomial in sequence
 	accum := big.NewInt(1)
 	num := big.NewInt(int64(len(b.vals) - 1))
@@ -75,56 +93,145 @@ func (b *binomial) populate() {
 		num.Sub(num, big.NewInt(1))
 	}
 
+	b.checkSum()
+}
+
+// checkSum sets b.sum and checks that it is 2^length
+func (b *binomial) checkSum() {
 	// Check that the sum of the binomials equals 2^N
 	b.sum = new(big.Int)
 	for _, val := range b.vals {
 		b.sum.Add(b.sum, val)
 	}
-	power := big.NewInt(1)
-	for n := 1; n <= len(b.vals)-1; n++ {
-		power.Mul(power, big.NewInt(2))
-	}
-	if b.sum.Cmp(power) != 0 {
-		panic(fmt.Errorf("bad binomial sum"))
+	if !isPowerOfTwo(b.sum, len(b.vals)-1) {
+		panic(fmt.Errorf("%w: bad binomial sum", ErrInternalCheckFailed))
 	}
 }
 
+// Tracer observes the recursion, for example to visualize the tree. Enter is called each time the recursion
+// picks entry 'chosenResidue' of row 'level' of the sums matrix, with the accumulator after multiplying by it.
+// The branch is then pruned if the accumulator is zero. Leaf is called at the end of each complete branch with
+// the residue of the overall sum and the number of subsets it accounts for. The accumulators must not be modified
+type Tracer interface {
+	Enter(level, chosenResidue int, accum *big.Int)
+	Leaf(finalResidue int, accum *big.Int)
+}
+
 // structure that has everything we need to pass during recursion
 type recurse struct {
-	binom  binomial
-	sums   [columns][columns]*big.Int
+	// the problem is the subsets of {1,...,n} modulo m, so there are m columns
+	n int
+	m int
+
+	// how many elements each column has, and the binomial coefficients for each column length. When m does
+	// not divide n the columns for residues 1 ... n%m have one more entry than the others
+	counts []int
+	binom  map[int]*binomial
+	sums   [][]*big.Int
 	mod    int
 	accum  *big.Int
-	totals [columns]*big.Int
+	totals []*big.Int
+
+	// optional, called after each of the branches from expandBranches completes, with how many of them are done
+	// and a copy of the totals so far. The last call receives the complete totals. It is not called when tracing
+	// or collecting statistics, which follow the whole recursion from level 0
+	onBranch func(done, branches int, totals []*big.Int)
+
+	// optional, observes every step of the recursion
+	tracer Tracer
+
+	// optional, collects the time spent and branches spawned at each level
+	stats *RecursionStats
+
+	// number of goroutines to share the recursion among, 0 or 1 for none.
+	// Tracing, statistics and branch snapshots need the recursion to run in order, so they always use one
+	workers int
+
+	// optional, the recursion stops early once this is closed, leaving the totals found so far
+	done    <-chan struct{}
+	stopped bool
 }
 
 func (r *recurse) initialize() {
-	// compute binomial coefficients
-	r.binom.populate()
+	checkModulus(r.m)
+	checkN(r.n)
+
+	// get the binomial coefficients for each distinct column length. They are only read,
+	// so the cached coefficients are shared instead of copied
+	r.counts = RangeResidueCounts(r.n, r.m)
+	r.binom = make(map[int]*binomial)
+	for _, length := range r.counts {
+		if r.binom[length] == nil {
+			b := &binomial{vals: sharedBinomials(length)}
+			b.checkSum()
+			r.binom[length] = b
+		}
+	}
 
 	// populate the totals, each entry initializes to zero
-	for i, row := range r.sums {
+	r.sums = make([][]*big.Int, r.m)
+	r.totals = make([]*big.Int, r.m)
+	for i := range r.sums {
 		r.totals[i] = new(big.Int)
-		for j, _ := range row {
+		r.sums[i] = make([]*big.Int, r.m)
+		for j := range r.sums[i] {
 			r.sums[i][j] = new(big.Int)
 		}
 	}
 }
 
-// Compute the 2x2 modulo totals array
+// contributionResidue returns the residue modulo m of the sum of chosenCount elements which are all congruent to
+// classResidue modulo m, which is chosenCount * classResidue modulo m. Both are reduced into [0, m) first, and
+// the product of two residues is taken in 128 bits since it needs more than 64 once m passes 2^32, so nothing
+// can overflow for any m, and negative arguments work too
+func contributionResidue(chosenCount, classResidue, m int) int {
+	a, b := chosenCount%m, classResidue%m
+	if a < 0 {
+		a += m
+	}
+	if b < 0 {
+		b += m
+	}
+	hi, lo := bits.Mul64(uint64(a), uint64(b))
+	_, c := bits.Div64(hi, lo, uint64(m))
+	return int(c)
+}
+
+// Compute the mxm modulo totals array
 func (r *recurse) computeColumnModuloTotals() {
-	// Each column contains values with a constant modulo from zero to columns -1
-	for mod := 0; mod < columns; mod++ {
-		for k, b := range r.binom.vals {
-			// The binomial coefficient 'b' represents how many ways to select 'k' items from this column
-			// Each item in this column is 'mod' modulo 'columns' and therefore these k items comtribute k * mod % columns to the sum
-			contribution := (k * mod) % columns
+	// The contribution k * mod % m only depends on k modulo m, so first add up the binomial coefficients
+	// by k modulo m. This only depends on the column length, so it is done once for each of the (at most two)
+	// lengths and shared by all the columns of that length
+	buckets := make(map[int][]*big.Int, len(r.binom))
+	for length, binom := range r.binom {
+		bucket := make([]*big.Int, r.m)
+		for j := range bucket {
+			bucket[j] = new(big.Int)
+		}
+		for k, j := 0, 0; k < len(binom.vals); k++ {
+			bucket[j].Add(bucket[j], binom.vals[k])
+			if j++; j == r.m {
+				j = 0
+			}
+		}
+		buckets[length] = bucket
+	}
+
+	// Each column contains values with a constant modulo from zero to m - 1
+	for mod := 0; mod < r.m; mod++ {
+		for j, b := range buckets[r.counts[mod]] {
+			// 'b' represents how many ways to select some number of items k = j modulo m from this column
+			// Each item in this column is 'mod' modulo m and therefore these k items comtribute j * mod % m to the sum
+			contribution := contributionResidue(j, mod, r.m)
 			// this next statement is actually just r.sums[mod][contribution] += b (in big.Int semantics)
 			r.sums[mod][contribution].Add(r.sums[mod][contribution], b)
 		}
 	}
 }
 
+// Each level of the recursion picks one entry from row 'level' of the sums matrix. Since addition modulo
+// m and multiplication are both commutative, the totals do not depend on the order in which the rows
+// are visited, so the rows may be reordered (or split among workers) without changing the result
 func (r *recurse) doNextLevel(level int) {
 	// If the accumulator is zero, it won't contribute
 	if r.accum.Cmp(big.NewInt(0)) == 0 {
@@ -132,29 +239,63 @@ func (r *recurse) doNextLevel(level int) {
 	}
 
 	// recursion ends when the level equals the number of columns
-	if level == columns {
+	if level == r.m {
 		// the accumulator has the total ways
+		if r.tracer != nil {
+			r.tracer.Leaf(r.mod, r.accum)
+		}
+		if r.stats != nil {
+			r.stats.Leaves++
+		}
 		r.totals[r.mod].Add(r.totals[r.mod], r.accum)
 		r.accum = nil
 		return
 	}
 
+	// stop early if asked to
+	if r.stopped {
+		return
+	}
+	if r.done != nil {
+		select {
+		case <-r.done:
+			r.stopped = true
+			return
+		default:
+		}
+	}
+
+	if r.stats != nil {
+		start := time.Now()
+		r.stats.Levels[level].Visits++
+		defer func() { r.stats.Levels[level].Time += time.Since(start) }()
+	}
+
 	// Go through each column at this level.
-	for n := 0; n < columns; n++ {
+	for n := 0; n < r.m; n++ {
 		// save old
 		oldMod := r.mod
 		oldAccum := r.accum
 
-		// multiply accumulator by number of subsets of items from column 'level' that have sum 'n' modulo 'columns'
+		// multiply accumulator by number of subsets of items from column 'level' that have sum 'n' modulo m
 		r.accum = new(big.Int)
 		r.accum.Mul(oldAccum, r.sums[level][n])
 
-		// Since these subsets have sum 'n' modulo 'columns' they increase the overall sum by 'n'
+		// Since these subsets have sum 'n' modulo m they increase the overall sum by 'n'
 		r.mod += n
-		if r.mod >= columns {
-			r.mod -= columns
+		if r.mod >= r.m {
+			r.mod -= r.m
 		}
 
+		if r.tracer != nil {
+			r.tracer.Enter(level, n, r.accum)
+		}
+		if r.stats != nil {
+			r.stats.Levels[level].Branches++
+			if r.accum.Sign() == 0 {
+				r.stats.Levels[level].Pruned++
+			}
+		}
 		r.doNextLevel(level + 1)
 
 		// restore old
@@ -163,41 +304,144 @@ func (r *recurse) doNextLevel(level int) {
 	}
 }
 
+// result packages the totals of a completed recursion
+func (r *recurse) result() *Result {
+	return &Result{N: r.n, M: r.m, Totals: r.totals}
+}
+
+// snapshot returns a copy of the current totals which is safe to keep while the recursion continues
+func (r *recurse) snapshot() []*big.Int {
+	totals := make([]*big.Int, r.m)
+	for i, t := range r.totals {
+		totals[i] = new(big.Int).Set(t)
+	}
+	return totals
+}
+
 func (r *recurse) computeTotalsRecursively() {
 	// initialize level zero
 	r.mod = 0
 	r.accum = big.NewInt(1)
 
 	// perform the recursion
-	r.doNextLevel(0)
+	switch {
+	case r.tracer != nil || r.stats != nil:
+		r.doNextLevel(0)
+	case r.onBranch != nil:
+		r.computeTotalsByBranch()
+	case r.workers > 1:
+		r.computeTotalsInParallel()
+	default:
+		r.doNextLevel(0)
+	}
+
+	// partial totals can't be checked
+	if r.stopped {
+		return
+	}
+	r.checkTotals()
+}
 
+// checkTotals checks the totals of a completed recursion
+func (r *recurse) checkTotals() {
 	// Check result: first add the total columns
 	sum := big.NewInt(0)
 	for _, t := range r.totals {
 		sum.Add(sum, t)
 	}
 
-	// Total should be 2^rows*columns
-	power := big.NewInt(1)
-	for n := 1; n <= rows*columns; n++ {
-		power.Mul(power, big.NewInt(2))
+	// Total should be 2^n
+	if !isPowerOfTwo(sum, r.n) {
+		panic(fmt.Errorf("%w: bad total sum", ErrInternalCheckFailed))
 	}
-	if sum.Cmp(power) != 0 {
-		panic(fmt.Errorf("bad total sum"))
+	if err := checkNonNegative(r.totals); err != nil {
+		panic(err)
+	}
+}
+
+// recursion computes the distribution of subset sums of {1,...,n} modulo m with the binomial method
+func recursion(n, m int) *Result {
+	r := &recurse{n: n, m: m, workers: int(recursionWorkers.Load())}
+	r.initialize()
+
+	r.computeColumnModuloTotals()
+
+	r.computeTotalsRecursively()
+
+	return r.result()
+}
+
+// recursionContext is recursion that stops early when ctx is done. In that case the totals found
+// so far are returned along with the context's error. If onBranch is not nil it is called with the totals
+// so far after each branch, and the branches run one at a time
+func recursionContext(ctx context.Context, n, m int, onBranch func(done, branches int, totals []*big.Int)) (*Result, error) {
+	r := &recurse{n: n, m: m, done: ctx.Done(), workers: int(recursionWorkers.Load()), onBranch: onBranch}
+	r.initialize()
+
+	r.computeColumnModuloTotals()
+
+	r.computeTotalsRecursively()
+
+	if r.stopped {
+		return r.result(), ctx.Err()
+	}
+	return r.result(), nil
+}
+
+// interruptibleRecursion runs the recursion until it completes or the user presses Ctrl-C,
+// in which case the partial count is printed and the program exits. If checkpointPath is not empty,
+// progress is saved there and an interrupted run carries on from it the next time. With progress set,
+// the running count is printed to stderr after each branch
+func interruptibleRecursion(w io.Writer, n, m int, checkpointPath string, progress bool) *Result {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	var onBranch func(done, branches int, totals []*big.Int)
+	if progress {
+		onBranch = func(done, branches int, totals []*big.Int) {
+			fmt.Fprintf(os.Stderr, "branch %d of %d done, running count %s\n", done, branches, humanize(totals[0]))
+		}
+	}
+
+	var res *Result
+	var err error
+	if checkpointPath != "" {
+		res, err = recursionCheckpointed(ctx, n, m, checkpointPath, onBranch)
+		if err != nil && ctx.Err() == nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	} else {
+		res, err = recursionContext(ctx, n, m, onBranch)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "interrupted, the result is incomplete")
+		fmt.Fprintln(w, "Partial count of subsets whose sum is divisible by", m, "(incomplete):")
+		printResult(w, res, FormatText)
+		if checkpointPath != "" {
+			fmt.Fprintln(os.Stderr, "progress saved to", checkpointPath)
+		}
+		os.Exit(130)
 	}
+	return res
 }
 
 // Simple method. If we know the distribution of the sums of all possible subsets of {1...n}
 // then we compute the distribution of the sums of all possible subsets of {1...n+1} by adding
-// the known distribution plus the known distribution shifted by (n + 1 modulo 'columns')
-func simple() {
+// the known distribution plus the known distribution shifted by (n + 1 modulo m)
+func simple(n, m int) *Result {
+	if workers := int(recursionWorkers.Load()); workers > 1 && m >= parallelConvolutionMinM {
+		return simpleInParallel(n, m, workers)
+	}
+
 	// we will use a two dimensional array to hold a prev distribution and a next distribution which alternate
-	var sums [2][columns]*big.Int
+	var sums [2][]*big.Int
 	var prev, next int
 
 	// Initialize both arrays. They contain big.Int pointers
 	for prev = 0; prev < 2; prev++ {
-		for i := 0; i < columns; i++ {
+		sums[prev] = make([]*big.Int, m)
+		for i := 0; i < m; i++ {
 			sums[prev][i] = big.NewInt(0)
 		}
 	}
@@ -210,30 +454,249 @@ func simple() {
 	// So there is a single subset (the null subset) whose sum is zero
 	sums[prev][0].Set(big.NewInt(1))
 
-	// Iterate, advancing the known distribution each time
-	for n := 1; n <= rows*columns; n++ {
-		for col := 0; col < columns; col++ {
-			k := (n + col) % columns
-			// nth distribution is (n-1)th distribution plus (n-1)th distribution shifted by n mod columns
+	// Iterate, advancing the known distribution each time. The two distributions are reused for every
+	// element, so this takes O(n*m) additions and no allocations beyond the growth of the big.Ints
+	for i := 1; i <= n; i++ {
+		shift := i % m
+		for col := 0; col < m; col++ {
+			// k = (i + col) % m, without a division in the inner loop, which matters for large m
+			k := col + shift
+			if k >= m {
+				k -= m
+			}
+			// ith distribution is (i-1)th distribution plus (i-1)th distribution shifted by i mod m
 			sums[next][k].Add(sums[prev][k], sums[prev][col])
 		}
 		// alternate prev and next
 		prev, next = next, prev
 	}
-	fmt.Println("Number of subsets whose sum is divisible by", columns, "(simple method):")
-	fmt.Println(sums[prev][0])
+	if err := checkNonNegative(sums[prev]); err != nil {
+		panic(err)
+	}
+	return &Result{N: n, M: m, Totals: sums[prev]}
 }
 
 func main() {
-	simple()
+	showVersion := flag.Bool("version", false, "print version information and exit")
+	probability := flag.Bool("probability", false, "also print the probability of divisibility, to -float-prec bits")
+	n := flag.Int("n", rows*columns, "count subsets of {1,...,n}")
+	m := flag.Int("m", columns, "modulus the subset sums must be divisible by")
+	backend := flag.String("backend", "", "backend to use: auto, recursion, convolution or rootsofunity (default: run both and print each)")
+	verbose := flag.Bool("v", false, "print details of the computation to stderr")
+	quiet := flag.Bool("quiet", false, "print only the final number, without headers or details")
+	workers := flag.Int("workers", runtime.NumCPU(), "number of goroutines for the recursion, and the convolution for large moduli")
+	noEmpty := flag.Bool("no-empty", false, "leave the empty subset out of the counts, whatever the backend (the -probability value still includes it)")
+	digitsFlag := flag.Int("digits", 0, "print only this many leading digits of the count, with its digit count")
+	noCache := flag.Bool("no-cache", false, "do not cache binomial coefficients between computations")
+	formatName := flag.String("format", "text", "output format: text, python or python-dist")
+	explainFlag := flag.Bool("explain", false, "explain how the count is put together for this n and m")
+	batch := flag.String("batch", "", "read lines of n,m,r from this CSV file and write them with the count for each appended, using -backend (default auto) and -no-empty")
+	trials := flag.Int("trials", 0, "only time the computation this many times and print the min, median and max")
+	warm := flag.Bool("warm", false, "with -trials, keep the binomial cache between trials instead of timing each from cold (only the recursion uses it)")
+	statsFlag := flag.Bool("stats", false, "run the binomial method and print the time spent and branches spawned at each level to stderr")
+	floatPrec := flag.Uint("float-prec", 128, "bits of big.Float precision for every approximate output: -probability, -logspace, the chart deviations and the heatmap fractions")
+	logspace := flag.Bool("logspace", false, "only estimate the size of the count, which is fast for huge n")
+	factorDigits := flag.Int("factor", 0, "print the prime factorization of the count if it has at most this many digits")
+	factorTimeout := flag.Duration("factor-timeout", 10*time.Second, "give up factoring after this long")
+	weight := flag.String("weight", "", "count subsets whose sum of weights is divisible by m, with the weight of i given by an expression like \"i*i + 1\"")
+	checkpointPath := flag.String("checkpoint", "", "save the progress of the binomial method to this file, and resume from it if it exists")
+	progress := flag.Bool("progress", false, "print the running count of the binomial method to stderr after each of its branches")
+	splitDir := flag.String("split-output", "", "also write the count for each residue r to residue_r.txt in this directory")
+	flag.Parse()
+
+	if *showVersion {
+		printVersion(os.Stdout)
+		return
+	}
+	if *floatPrec < 1 {
+		fmt.Fprintf(os.Stderr, "bad -float-prec %d, must be at least 1\n", *floatPrec)
+		os.Exit(2)
+	}
 
-	r := &recurse{}
-	r.initialize()
+	switch flag.Arg(0) {
+	case "version":
+		printVersion(os.Stdout)
+		return
+	case "classic":
+		runClassic(os.Stdout)
+		return
+	case "selftest":
+		if !runSelftest(os.Stdout, flag.Args()[1:]) {
+			os.Exit(1)
+		}
+		return
+	case "bench":
+		if err := runBench(os.Stdout, flag.Args()[1:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	case "chart":
+		if err := runChart(os.Stdout, flag.Args()[1:], *floatPrec); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	case "heatmap":
+		if err := runHeatmap(flag.Args()[1:], *floatPrec); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
 
-	r.computeColumnModuloTotals()
+	w := os.Stdout
+	noBinomialCache.Store(*noCache)
+	printDigits.Store(int64(*digitsFlag))
+	recursionWorkers.Store(int64(*workers))
+	if *batch != "" {
+		name := *backend
+		if name == "" {
+			name = "auto"
+		}
+		if err := runBatch(w, os.Stderr, *batch, name, *noEmpty); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
 
-	r.computeTotalsRecursively()
+	format, err := parseOutputFormat(*formatName)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	for _, err := range []error{validateN(*n), validateModulus(*m)} {
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+	}
 
-	fmt.Println("Number of subsets whose sum is divisible by", columns, "(binomial method):")
-	fmt.Println(r.totals[0])
+	// -quiet wins over -v
+	if *quiet {
+		*verbose = false
+	}
+	header := func(line string) {
+		if !*quiet {
+			printHeader(w, format, line)
+		}
+	}
+	// every backend counts the empty subset, so -no-empty takes it out of their results afterwards
+	finish := func(r *Result) *Result {
+		if *noEmpty {
+			return r.WithoutEmpty()
+		}
+		return r
+	}
+
+	if *verbose {
+		// the columns have n/m elements, plus one for the residues 1 ... n%m
+		printBinomialSummary(os.Stderr, *n / *m)
+		if *n%*m != 0 {
+			printBinomialSummary(os.Stderr, *n / *m + 1)
+		}
+	}
+
+	if *explainFlag {
+		explain(w, *n, *m)
+		return
+	}
+
+	if *trials > 0 {
+		name := *backend
+		if name == "" {
+			name = "auto"
+		}
+		if err := runTrials(w, name, *n, *m, *trials, *warm); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		return
+	}
+
+	if *statsFlag {
+		res, stats := recursionWithStats(*n, *m)
+		header(fmt.Sprintf("Number of subsets whose sum is divisible by %d (binomial method):", *m))
+		printResult(w, finish(res), format)
+		stats.print(os.Stderr)
+		return
+	}
+
+	if *logspace {
+		digits, leading := LogspaceEstimate(*n, *m, *floatPrec)
+		header(fmt.Sprintf("Approximate number of subsets whose sum is divisible by %d (logspace estimate):", *m))
+		fmt.Fprintf(w, "%se+%d (%d digits)\n", leading, digits-1, digits)
+		return
+	}
+
+	if *weight != "" {
+		e, err := parseWeightExpr(*weight)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		count := CountWeightedDivisible(*n, *m, func(i int) int { return e.eval(i, *m) })
+		if *noEmpty {
+			// the empty subset's weights add up to 0
+			count.Sub(count, big.NewInt(1))
+		}
+		header(fmt.Sprintf("Number of subsets whose sum of weights %s is divisible by %d:", *weight, *m))
+		fmt.Fprintln(w, count)
+		return
+	}
+
+	var res *Result
+	if *backend == "" {
+		// both methods give the same number, so -quiet only prints it once
+		if !*quiet {
+			header(fmt.Sprintf("Number of subsets whose sum is divisible by %d (simple method):", *m))
+			printResult(w, finish(ResidueDistribution(*n, *m)), format)
+		}
+
+		res = finish(interruptibleRecursion(w, *n, *m, *checkpointPath, *progress))
+		header(fmt.Sprintf("Number of subsets whose sum is divisible by %d (binomial method):", *m))
+		printResult(w, res, format)
+	} else {
+		name, compute, err := selectBackend(*backend, *n, *m)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		if *verbose {
+			fmt.Fprintln(os.Stderr, "using backend", name)
+		}
+		if name == "recursion" {
+			res = interruptibleRecursion(w, *n, *m, *checkpointPath, *progress)
+		} else {
+			res = compute(*n, *m)
+		}
+		res = finish(res)
+		header(fmt.Sprintf("Number of subsets whose sum is divisible by %d (%s backend):", *m, name))
+		printResult(w, res, format)
+	}
+
+	if *splitDir != "" {
+		if err := writeSplitOutput(*splitDir, res); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+
+	if count := res.Totals[0]; *factorDigits > 0 && count.Sign() > 0 && len(count.String()) <= *factorDigits {
+		f := factorize(count, time.Now().Add(*factorTimeout))
+		if f.complete() {
+			header("Prime factorization of the count:")
+		} else {
+			header("Prime factorization of the count (partially factored, the parenthesized factors are composite):")
+		}
+		fmt.Fprintln(w, f)
+	}
+
+	if *probability {
+		// each decimal digit needs log2(10) bits
+		digits := int(float64(*floatPrec)/math.Log2(10)) + 1
+		header(fmt.Sprintf("Probability that the sum of a subset is divisible by %d:", *m))
+		fmt.Fprintln(w, DivisibleProbability(*n, *m, *floatPrec).Text('g', digits))
+	}
 }
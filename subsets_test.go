@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+	"math/big"
+	"math/rand"
+	"testing"
+)
+
+// directColumnModuloTotals builds the sums matrix the way computeColumnModuloTotals did before the binomial
+// coefficients were bucketed, with one big.Int addition per coefficient of every column
+func directColumnModuloTotals(r *recurse) {
+	for mod := 0; mod < r.m; mod++ {
+		for k, b := range r.binom[r.counts[mod]].vals {
+			contribution := contributionResidue(k, mod, r.m)
+			r.sums[mod][contribution].Add(r.sums[mod][contribution], b)
+		}
+	}
+}
+
+func TestComputeColumnModuloTotalsMatchesDirect(t *testing.T) {
+	for _, tc := range []struct{ n, m int }{{0, 1}, {7, 1}, {2000, 5}, {23, 5}, {10, 3}, {3, 7}, {100, 12}} {
+		r := &recurse{n: tc.n, m: tc.m}
+		r.initialize()
+		r.computeColumnModuloTotals()
+
+		direct := &recurse{n: tc.n, m: tc.m}
+		direct.initialize()
+		directColumnModuloTotals(direct)
+
+		for mod := range r.sums {
+			if !sameCounts(r.sums[mod], direct.sums[mod]) {
+				t.Errorf("n = %d, m = %d: row %d is %v, want %v", tc.n, tc.m, mod, r.sums[mod], direct.sums[mod])
+			}
+		}
+	}
+}
+
+// BenchmarkComputeColumnModuloTotals builds the sums matrix for the original problem, m = 5 with 400 rows, both
+// bucketed and with one addition per binomial coefficient
+func BenchmarkComputeColumnModuloTotals(b *testing.B) {
+	for _, bc := range []struct {
+		name string
+		fill func(r *recurse)
+	}{
+		{"bucketed", (*recurse).computeColumnModuloTotals},
+		{"direct", directColumnModuloTotals},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			r := &recurse{n: rows * columns, m: columns}
+			r.initialize()
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				for _, row := range r.sums {
+					for _, s := range row {
+						s.SetInt64(0)
+					}
+				}
+				b.StartTimer()
+				bc.fill(r)
+			}
+		})
+	}
+}
+
+func TestOnBranchSnapshots(t *testing.T) {
+	for _, tc := range []struct{ n, m int }{{30, 5}, {100, 7}, {11, 1}, {9, 2}} {
+		var snapshots [][]*big.Int
+		var counts []int
+		res, err := recursionContext(context.Background(), tc.n, tc.m, func(done, branches int, totals []*big.Int) {
+			if done != len(snapshots)+1 {
+				t.Errorf("n = %d, m = %d: branch %d reported after %d others", tc.n, tc.m, done, len(snapshots))
+			}
+			snapshots = append(snapshots, totals)
+			counts = append(counts, branches)
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(snapshots) == 0 {
+			t.Fatalf("n = %d, m = %d: no snapshots", tc.n, tc.m)
+		}
+		if counts[0] != len(snapshots) {
+			t.Errorf("n = %d, m = %d: %d snapshots for %d branches", tc.n, tc.m, len(snapshots), counts[0])
+		}
+		if last := snapshots[len(snapshots)-1]; !sameCounts(last, res.Totals) {
+			t.Errorf("n = %d, m = %d: last snapshot %v is not the result %v", tc.n, tc.m, last, res.Totals)
+		}
+
+		// each snapshot is a running count, so it only ever grows, and it is a copy which later branches don't change
+		for i := 1; i < len(snapshots); i++ {
+			prev, cur := new(big.Int), new(big.Int)
+			for j := range snapshots[i] {
+				prev.Add(prev, snapshots[i-1][j])
+				cur.Add(cur, snapshots[i][j])
+			}
+			if cur.Cmp(prev) <= 0 {
+				t.Errorf("n = %d, m = %d: snapshot %d holds %v subsets, no more than the %v before it", tc.n, tc.m, i, cur, prev)
+			}
+		}
+	}
+}
+
+func TestRecursionIndependentOfRowOrder(t *testing.T) {
+	rng := rand.New(rand.NewSource(107))
+	for _, tc := range []struct{ n, m, unit int }{{2000, 5, 2}, {50, 7, 3}, {23, 6, 5}, {40, 8, 3}} {
+		r := &recurse{n: tc.n, m: tc.m}
+		r.initialize()
+		r.computeColumnModuloTotals()
+		r.computeTotalsRecursively()
+		want := r.snapshot()
+
+		for trial := 0; trial < 5; trial++ {
+			// visit the rows in a random order, and relabel every residue c as unit * c, which permutes Z/mZ
+			s := &recurse{n: tc.n, m: tc.m}
+			s.initialize()
+			perm := rng.Perm(tc.m)
+			for i, p := range perm {
+				for c, v := range r.sums[p] {
+					s.sums[i][c*tc.unit%tc.m].Set(v)
+				}
+			}
+			s.computeTotalsRecursively()
+
+			if s.totals[0].Cmp(want[0]) != 0 {
+				t.Errorf("n = %d, m = %d, rows %v: totals[0] = %v, want %v", tc.n, tc.m, perm, s.totals[0], want[0])
+			}
+			for c := range want {
+				if got := s.totals[c*tc.unit%tc.m]; got.Cmp(want[c]) != 0 {
+					t.Errorf("n = %d, m = %d, rows %v: residue %d relabeled has %v, want %v", tc.n, tc.m, perm, c, got, want[c])
+				}
+			}
+		}
+	}
+}
+
+func TestContributionResidue(t *testing.T) {
+	const large = 1 << 40
+	for _, tc := range []struct{ k, mod, m, want int }{
+		{0, 3, 5, 0},
+		{4, 0, 5, 0},
+		{3, 4, 5, 2},
+		{7, 7, 1, 0},
+		{5, 2, 10, 0},
+		{-1, 3, 5, 2},
+		{2, -1, 5, 3},
+		// k * mod would overflow without reducing both first
+		{large + 1, large + 2, 7, ((large + 1) % 7) * ((large + 2) % 7) % 7},
+		// 2^40 is -1 modulo 2^40 + 1, and the product of the residues needs 80 bits
+		{large, large, large + 1, 1},
+	} {
+		if got := contributionResidue(tc.k, tc.mod, tc.m); got != tc.want {
+			t.Errorf("contributionResidue(%d, %d, %d) = %d, want %d", tc.k, tc.mod, tc.m, got, tc.want)
+		}
+	}
+
+	for m := 1; m <= 12; m++ {
+		for k := 0; k <= 30; k++ {
+			for mod := 0; mod < m; mod++ {
+				if got := contributionResidue(k, mod, m); got != k*mod%m {
+					t.Errorf("contributionResidue(%d, %d, %d) = %d, want %d", k, mod, m, got, k*mod%m)
+				}
+			}
+		}
+	}
+}
+
+func TestIsPowerOfTwo(t *testing.T) {
+	for _, tc := range []struct {
+		x    *big.Int
+		e    int
+		want bool
+	}{
+		{big.NewInt(1), 0, true},
+		{big.NewInt(2), 1, true},
+		{big.NewInt(1024), 10, true},
+		{big.NewInt(1024), 9, false},
+		{big.NewInt(1023), 10, false},
+		{big.NewInt(1536), 10, false},
+		{big.NewInt(0), 0, false},
+		{big.NewInt(-4), 2, false},
+		{new(big.Int).Lsh(big.NewInt(1), 5000), 5000, true},
+		{new(big.Int).Lsh(big.NewInt(3), 4999), 5000, false},
+	} {
+		if got := isPowerOfTwo(tc.x, tc.e); got != tc.want {
+			t.Errorf("isPowerOfTwo(%v, %d) = %t, want %t", tc.x, tc.e, got, tc.want)
+		}
+	}
+
+	// 2^(2^40) would take 128GB, the check must not build it
+	x := new(big.Int).Lsh(big.NewInt(1), 5000)
+	if allocs := testing.AllocsPerRun(10, func() { isPowerOfTwo(x, 1<<40) }); allocs != 0 {
+		t.Errorf("checking against 2^(2^40) made %v allocations", allocs)
+	}
+}
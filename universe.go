@@ -0,0 +1,220 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"math/bits"
+)
+
+// CountDivisibleExcluding counts the subsets of {1,...,n} \ exclude whose sum is divisible by m.
+// Every excluded value must be in [1, n], duplicates are ignored
+func CountDivisibleExcluding(n, m int, exclude []int) *big.Int {
+	// how many elements of {1,...,n} fall in each column
+	counts := RangeResidueCounts(n, m)
+
+	// take each excluded value out of its column, once
+	seen := make(map[int]bool, len(exclude))
+	for _, x := range exclude {
+		if x < 1 || x > n {
+			panic(fmt.Errorf("%w: excluded value %d is not in {1,...,%d}", ErrElementOutOfRange, x, n))
+		}
+		if seen[x] {
+			continue
+		}
+		seen[x] = true
+		counts[x%m]--
+	}
+	return distributionFromCounts(counts)[0]
+}
+
+// CountUsingResidueClasses counts the subsets of {1,...,n} that only use elements whose residue modulo m is in
+// classes, and whose sum is congruent to target modulo m. The other columns are forced empty, so their rows of
+// the sums matrix are replaced by the identity, which is the same as giving those columns no elements.
+// Every class must be a residue modulo m, duplicates are ignored
+func CountUsingResidueClasses(n, m int, classes []int, target int) *big.Int {
+	checkResidue(target, m)
+	all := RangeResidueCounts(n, m)
+
+	counts := make([]int, m)
+	for _, v := range classes {
+		checkResidue(v, m)
+		counts[v] = all[v]
+	}
+	return distributionFromCounts(counts)[target]
+}
+
+// CountAPDivisible counts the subsets of the arithmetic progression {a, a+d, ..., a+(count-1)d} whose sum
+// is divisible by m. The residues of the terms repeat with period m/gcd(d, m), so the column counts
+// are the number of complete periods plus one for the terms of the final partial period
+func CountAPDivisible(a, d, count, m int) *big.Int {
+	checkModulus(m)
+	return distributionFromCounts(apResidueCounts(AP{Start: a, Step: d, Count: count}, m))[0]
+}
+
+// AP is the arithmetic progression {Start, Start+Step, ..., Start+(Count-1)Step}
+type AP struct {
+	Start, Step, Count int
+}
+
+// apResidueCounts returns how many terms of ap fall in each residue class modulo m
+func apResidueCounts(ap AP, m int) []int {
+	checkN(ap.Count)
+	period := m / gcd(ap.Step, m)
+	counts := make([]int, m)
+	for t := 0; t < period; t++ {
+		v := ((ap.Start+t*ap.Step)%m + m) % m
+		counts[v] += ap.Count / period
+		if t < ap.Count%period {
+			counts[v]++
+		}
+	}
+	return counts
+}
+
+// CountUnionOfAPsDivisible counts the subsets of the union of the arithmetic progressions whose sum is divisible
+// by m. The progressions are assumed to be disjoint: the column counts of each are added together, so a value in
+// two of them counts as two separate elements, as repeated values do in ElementsSpec
+func CountUnionOfAPsDivisible(aps []AP, m int) *big.Int {
+	checkModulus(m)
+	counts := make([]int, m)
+	for _, ap := range aps {
+		for v, c := range apResidueCounts(ap, m) {
+			counts[v] += c
+		}
+	}
+	return distributionFromCounts(counts)[0]
+}
+
+// CountWithMandatory counts the subsets of {1,...,n} that contain every element of mandatory and whose sum is
+// congruent to target modulo m. The mandatory elements always add the same fixed amount, so this is the number
+// of subsets of the remaining elements whose sum is congruent to target minus that amount.
+// The mandatory elements must be distinct and in [1, n]
+func CountWithMandatory(n, m int, mandatory []int, target int) *big.Int {
+	checkResidue(target, m)
+	counts := RangeResidueCounts(n, m)
+
+	seen := make(map[int]bool, len(mandatory))
+	fixed := 0
+	for _, x := range mandatory {
+		if x < 1 || x > n {
+			panic(fmt.Errorf("%w: mandatory element %d is not in {1,...,%d}", ErrElementOutOfRange, x, n))
+		}
+		if seen[x] {
+			panic(fmt.Errorf("%w: mandatory element %d is repeated", ErrDuplicateElement, x))
+		}
+		seen[x] = true
+		counts[x%m]--
+		fixed = (fixed + x) % m
+	}
+	return distributionFromCounts(counts)[((target-fixed)%m+m)%m]
+}
+
+// CountDistinctResiduesDivisible counts the subsets of {1,...,n} whose elements are pairwise distinct modulo m
+// and whose sum is divisible by m. Each column contributes either nothing or exactly one of its elements,
+// so its row is 1 + count*x^residue instead of the full binomial row
+func CountDistinctResiduesDivisible(n, m int) *big.Int {
+	counts := RangeResidueCounts(n, m)
+
+	dist := make([]*big.Int, m)
+	for r := range dist {
+		dist[r] = new(big.Int)
+	}
+	dist[0].SetInt64(1)
+
+	for v, count := range counts {
+		row := make([]*big.Int, m)
+		for r := range row {
+			row[r] = new(big.Int)
+		}
+		row[0].SetInt64(1)
+		row[v].Add(row[v], big.NewInt(int64(count)))
+		dist = convolve(dist, row)
+	}
+	return dist[0]
+}
+
+// CountSymmetricDifferenceDivisible counts the subsets of the symmetric difference of the sets a and b
+// whose sum is divisible by m. Neither a nor b may contain a value twice
+func CountSymmetricDifferenceDivisible(a, b []int, m int) *big.Int {
+	checkModulus(m)
+
+	// inA and inB record membership, the symmetric difference is the values in exactly one of them
+	inA := make(map[int]bool, len(a))
+	for _, x := range a {
+		if inA[x] {
+			panic(fmt.Errorf("%w: %d is repeated in the first set", ErrDuplicateElement, x))
+		}
+		inA[x] = true
+	}
+	inB := make(map[int]bool, len(b))
+	for _, x := range b {
+		if inB[x] {
+			panic(fmt.Errorf("%w: %d is repeated in the second set", ErrDuplicateElement, x))
+		}
+		inB[x] = true
+	}
+
+	var diff []int
+	for _, x := range a {
+		if !inB[x] {
+			diff = append(diff, x)
+		}
+	}
+	for _, x := range b {
+		if !inA[x] {
+			diff = append(diff, x)
+		}
+	}
+	return distributionFromCounts(ElementsSpec(diff, m, 0).ResidueCounts)[0]
+}
+
+// maxForbiddenPairs bounds CountDivisibleWithForbiddenPairs, whose inclusion-exclusion visits
+// every subset of the forbidden pairs
+const maxForbiddenPairs = 24
+
+// CountDivisibleWithForbiddenPairs counts the subsets of {1,...,n} with sum divisible by m that do not contain
+// both elements of any of the forbidden pairs. This is outside the column model, so it uses inclusion-exclusion:
+// for each set P of pairs, the subsets containing every pair in P are counted with CountWithMandatory, with
+// sign (-1)^|P|. That is 2^len(pairs) terms, so at most maxForbiddenPairs pairs are allowed
+func CountDivisibleWithForbiddenPairs(n, m int, pairs [][2]int) *big.Int {
+	checkModulus(m)
+	checkN(n)
+	if len(pairs) > maxForbiddenPairs {
+		panic(fmt.Errorf("%w: %d forbidden pairs, the limit is %d", ErrTooManyConstraints, len(pairs), maxForbiddenPairs))
+	}
+	for _, p := range pairs {
+		for _, x := range p {
+			if x < 1 || x > n {
+				panic(fmt.Errorf("%w: forbidden pair element %d is not in {1,...,%d}", ErrElementOutOfRange, x, n))
+			}
+		}
+		if p[0] == p[1] {
+			panic(fmt.Errorf("%w: forbidden pair %v repeats an element", ErrDuplicateElement, p))
+		}
+	}
+
+	count := new(big.Int)
+	for mask := 0; mask < 1<<len(pairs); mask++ {
+		// the elements of the chosen pairs must all be present
+		seen := make(map[int]bool)
+		var mandatory []int
+		for i, p := range pairs {
+			if mask&(1<<i) == 0 {
+				continue
+			}
+			for _, x := range p {
+				if !seen[x] {
+					seen[x] = true
+					mandatory = append(mandatory, x)
+				}
+			}
+		}
+		term := CountWithMandatory(n, m, mandatory, 0)
+		if bits.OnesCount(uint(mask))%2 == 0 {
+			count.Add(count, term)
+		} else {
+			count.Sub(count, term)
+		}
+	}
+	return count
+}
@@ -0,0 +1,311 @@
+package main
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+)
+
+// countBruteForce counts the subsets of elems for which keep is true
+func countBruteForce(elems []int, keep func(subset []int) bool) *big.Int {
+	count := int64(0)
+	forEachSubset(elems, func(subset []int) {
+		if keep(subset) {
+			count++
+		}
+	})
+	return big.NewInt(count)
+}
+
+// divisibleBy returns a filter keeping the subsets whose sum is divisible by m
+func divisibleBy(m int) func(subset []int) bool {
+	return func(subset []int) bool { return sumOf(subset)%m == 0 }
+}
+
+func TestCountDivisibleExcludingBruteForce(t *testing.T) {
+	for _, tc := range []struct {
+		n, m    int
+		exclude []int
+	}{
+		{12, 5, nil},
+		{12, 5, []int{5, 10}},
+		{12, 5, []int{1, 1, 12, 7}},
+		{10, 3, []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}},
+		{14, 4, []int{13, 2}},
+		{9, 1, []int{9}},
+	} {
+		excluded := make(map[int]bool)
+		for _, x := range tc.exclude {
+			excluded[x] = true
+		}
+		var elems []int
+		for i := 1; i <= tc.n; i++ {
+			if !excluded[i] {
+				elems = append(elems, i)
+			}
+		}
+		want := countBruteForce(elems, divisibleBy(tc.m))
+		if got := CountDivisibleExcluding(tc.n, tc.m, tc.exclude); got.Cmp(want) != 0 {
+			t.Errorf("CountDivisibleExcluding(%d, %d, %v) = %v, want %v", tc.n, tc.m, tc.exclude, got, want)
+		}
+	}
+
+	for _, x := range []int{0, 11, -3} {
+		if err := panicError(func() { CountDivisibleExcluding(10, 5, []int{x}) }); !errors.Is(err, ErrElementOutOfRange) {
+			t.Errorf("excluding %d from {1,...,10} gave %v, want ErrElementOutOfRange", x, err)
+		}
+	}
+}
+
+func TestCountAPDivisibleBruteForce(t *testing.T) {
+	// d = 1 starting at 1 is the range {1,...,n}
+	for _, tc := range []struct{ n, m int }{{0, 1}, {10, 5}, {2000, 5}, {100, 7}, {31, 12}} {
+		if got, want := CountAPDivisible(1, 1, tc.n, tc.m), ResidueDistribution(tc.n, tc.m).Totals[0]; got.Cmp(want) != 0 {
+			t.Errorf("CountAPDivisible(1, 1, %d, %d) = %v, want %v", tc.n, tc.m, got, want)
+		}
+	}
+
+	for _, a := range []int{-7, 0, 1, 4} {
+		for _, d := range []int{-3, 0, 1, 2, 5, 6} {
+			for _, count := range []int{0, 1, 7, 11} {
+				elems := make([]int, count)
+				for i := range elems {
+					elems[i] = a + i*d
+				}
+				for m := 1; m <= 8; m++ {
+					want := countBruteForce(elems, divisibleBy(m))
+					if got := CountAPDivisible(a, d, count, m); got.Cmp(want) != 0 {
+						t.Errorf("CountAPDivisible(%d, %d, %d, %d) = %v, want %v", a, d, count, m, got, want)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestCountUnionOfAPsDivisibleBruteForce(t *testing.T) {
+	for _, aps := range [][]AP{
+		nil,
+		{{Start: 1, Step: 1, Count: 0}},
+		{{Start: 1, Step: 2, Count: 6}, {Start: 2, Step: 2, Count: 6}},
+		{{Start: 3, Step: 5, Count: 4}, {Start: -10, Step: 3, Count: 5}, {Start: 100, Step: -7, Count: 4}},
+		{{Start: 0, Step: 6, Count: 3}, {Start: 5, Step: 0, Count: 4}, {Start: 2, Step: 4, Count: 7}},
+		// overlapping progressions, whose shared values count twice
+		{{Start: 1, Step: 1, Count: 8}, {Start: 2, Step: 3, Count: 5}},
+	} {
+		var elems []int
+		for _, ap := range aps {
+			for i := 0; i < ap.Count; i++ {
+				elems = append(elems, ap.Start+i*ap.Step)
+			}
+		}
+		for m := 1; m <= 9; m++ {
+			want := countBruteForce(elems, divisibleBy(m))
+			if got := CountUnionOfAPsDivisible(aps, m); got.Cmp(want) != 0 {
+				t.Errorf("CountUnionOfAPsDivisible(%v, %d) = %v, want %v", aps, m, got, want)
+			}
+		}
+	}
+
+	// the odd and even numbers up to 2n are {1,...,2n}
+	for _, tc := range []struct{ n, m int }{{1000, 5}, {50, 12}} {
+		aps := []AP{{Start: 1, Step: 2, Count: tc.n}, {Start: 2, Step: 2, Count: tc.n}}
+		if got, want := CountUnionOfAPsDivisible(aps, tc.m), ResidueDistribution(2*tc.n, tc.m).Totals[0]; got.Cmp(want) != 0 {
+			t.Errorf("odds and evens up to %d modulo %d: %v, want %v", 2*tc.n, tc.m, got, want)
+		}
+	}
+
+	for _, tc := range []struct {
+		aps  []AP
+		m    int
+		want error
+	}{
+		{[]AP{{Start: 1, Step: 1, Count: 3}}, 0, ErrInvalidModulus},
+		{[]AP{{Start: 1, Step: 1, Count: 3}, {Start: 1, Step: 1, Count: -1}}, 3, ErrNegativeN},
+	} {
+		if err := panicError(func() { CountUnionOfAPsDivisible(tc.aps, tc.m) }); !errors.Is(err, tc.want) {
+			t.Errorf("CountUnionOfAPsDivisible(%v, %d) gave %v, want %v", tc.aps, tc.m, err, tc.want)
+		}
+	}
+}
+
+func TestCountWithMandatoryBruteForce(t *testing.T) {
+	for _, tc := range []struct {
+		n, m      int
+		mandatory []int
+	}{
+		{10, 5, nil},
+		{10, 5, []int{3}},
+		{12, 4, []int{1, 12, 6}},
+		{9, 7, []int{9, 8, 7, 6, 5, 4, 3, 2, 1}},
+		{11, 1, []int{11}},
+		{13, 6, []int{2, 4}},
+	} {
+		required := make(map[int]bool)
+		for _, x := range tc.mandatory {
+			required[x] = true
+		}
+		for target := 0; target < tc.m; target++ {
+			want := countBruteForce(oneTo(tc.n), func(subset []int) bool {
+				have := 0
+				for _, e := range subset {
+					if required[e] {
+						have++
+					}
+				}
+				return have == len(required) && sumOf(subset)%tc.m == target
+			})
+			if got := CountWithMandatory(tc.n, tc.m, tc.mandatory, target); got.Cmp(want) != 0 {
+				t.Errorf("CountWithMandatory(%d, %d, %v, %d) = %v, want %v", tc.n, tc.m, tc.mandatory, target, got, want)
+			}
+		}
+	}
+
+	for _, tc := range []struct {
+		mandatory []int
+		want      error
+	}{
+		{[]int{0}, ErrElementOutOfRange},
+		{[]int{11}, ErrElementOutOfRange},
+		{[]int{4, 2, 4}, ErrDuplicateElement},
+	} {
+		if err := panicError(func() { CountWithMandatory(10, 5, tc.mandatory, 0) }); !errors.Is(err, tc.want) {
+			t.Errorf("mandatory %v gave %v, want %v", tc.mandatory, err, tc.want)
+		}
+	}
+}
+
+func TestCountDistinctResiduesDivisibleBruteForce(t *testing.T) {
+	for n := 0; n <= 12; n++ {
+		for m := 1; m <= 7; m++ {
+			want := countBruteForce(oneTo(n), func(subset []int) bool {
+				seen := make(map[int]bool)
+				for _, e := range subset {
+					if seen[e%m] {
+						return false
+					}
+					seen[e%m] = true
+				}
+				return sumOf(subset)%m == 0
+			})
+			if got := CountDistinctResiduesDivisible(n, m); got.Cmp(want) != 0 {
+				t.Errorf("CountDistinctResiduesDivisible(%d, %d) = %v, want %v", n, m, got, want)
+			}
+		}
+	}
+}
+
+func TestCountSymmetricDifferenceDivisibleBruteForce(t *testing.T) {
+	for _, tc := range []struct{ a, b []int }{
+		{nil, nil},
+		{[]int{1, 2, 3, 4, 5}, nil},
+		{[]int{1, 2, 3, 4, 5}, []int{4, 5, 6, 7}},
+		{[]int{-3, 0, 8, 11, 20}, []int{20, -3, 14, 2, 9, 1}},
+		{[]int{2, 4, 6}, []int{6, 4, 2}},
+	} {
+		inA, inB := make(map[int]bool), make(map[int]bool)
+		for _, x := range tc.a {
+			inA[x] = true
+		}
+		for _, x := range tc.b {
+			inB[x] = true
+		}
+		var diff []int
+		for _, x := range append(append([]int{}, tc.a...), tc.b...) {
+			if inA[x] != inB[x] {
+				diff = append(diff, x)
+			}
+		}
+		for m := 1; m <= 7; m++ {
+			want := countBruteForce(diff, divisibleBy(m))
+			if got := CountSymmetricDifferenceDivisible(tc.a, tc.b, m); got.Cmp(want) != 0 {
+				t.Errorf("CountSymmetricDifferenceDivisible(%v, %v, %d) = %v, want %v", tc.a, tc.b, m, got, want)
+			}
+		}
+	}
+
+	for _, tc := range []struct{ a, b []int }{{[]int{1, 2, 1}, nil}, {[]int{1}, []int{3, 3}}} {
+		if err := panicError(func() { CountSymmetricDifferenceDivisible(tc.a, tc.b, 3) }); !errors.Is(err, ErrDuplicateElement) {
+			t.Errorf("sets %v and %v gave %v, want ErrDuplicateElement", tc.a, tc.b, err)
+		}
+	}
+}
+
+func TestCountDivisibleWithForbiddenPairsBruteForce(t *testing.T) {
+	for _, pairs := range [][][2]int{
+		nil,
+		{{1, 2}},
+		{{1, 2}, {2, 3}, {3, 1}},
+		{{4, 9}, {4, 9}},
+		{{1, 12}, {2, 11}, {3, 10}, {5, 7}, {6, 8}},
+	} {
+		for _, m := range []int{1, 3, 5, 6} {
+			want := countBruteForce(oneTo(12), func(subset []int) bool {
+				has := make(map[int]bool)
+				for _, e := range subset {
+					has[e] = true
+				}
+				for _, p := range pairs {
+					if has[p[0]] && has[p[1]] {
+						return false
+					}
+				}
+				return sumOf(subset)%m == 0
+			})
+			if got := CountDivisibleWithForbiddenPairs(12, m, pairs); got.Cmp(want) != 0 {
+				t.Errorf("CountDivisibleWithForbiddenPairs(12, %d, %v) = %v, want %v", m, pairs, got, want)
+			}
+		}
+	}
+
+	for _, tc := range []struct {
+		pairs [][2]int
+		want  error
+	}{
+		{[][2]int{{0, 3}}, ErrElementOutOfRange},
+		{[][2]int{{3, 13}}, ErrElementOutOfRange},
+		{[][2]int{{5, 5}}, ErrDuplicateElement},
+		{make([][2]int, maxForbiddenPairs+1), ErrTooManyConstraints},
+	} {
+		if err := panicError(func() { CountDivisibleWithForbiddenPairs(12, 5, tc.pairs) }); !errors.Is(err, tc.want) {
+			t.Errorf("%d pairs starting %v gave %v, want %v", len(tc.pairs), tc.pairs[0], err, tc.want)
+		}
+	}
+}
+
+func TestCountUsingResidueClassesBruteForce(t *testing.T) {
+	for _, tc := range []struct {
+		m       int
+		classes []int
+	}{
+		{5, nil},
+		{5, []int{0, 1, 2, 3, 4}},
+		{6, []int{1, 3, 5}},
+		{7, []int{2, 2, 6}},
+		{4, []int{0}},
+		{1, []int{0}},
+	} {
+		allowed := make(map[int]bool)
+		for _, v := range tc.classes {
+			allowed[v] = true
+		}
+		var elems []int
+		for i := 1; i <= 12; i++ {
+			if allowed[i%tc.m] {
+				elems = append(elems, i)
+			}
+		}
+		want := bruteForceDistribution(elems, tc.m)
+		for target := 0; target < tc.m; target++ {
+			if got := CountUsingResidueClasses(12, tc.m, tc.classes, target); got.Cmp(want[target]) != 0 {
+				t.Errorf("CountUsingResidueClasses(12, %d, %v, %d) = %v, want %v", tc.m, tc.classes, target, got, want[target])
+			}
+		}
+	}
+
+	for _, classes := range [][]int{{5}, {-1}} {
+		if err := panicError(func() { CountUsingResidueClasses(12, 5, classes, 0) }); !errors.Is(err, ErrResidueOutOfRange) {
+			t.Errorf("classes %v gave %v, want ErrResidueOutOfRange", classes, err)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"runtime/debug"
+)
+
+// printVersion writes the module version, the Go version and the VCS revision of this binary.
+// go install records the module version, go build from a checkout records the VCS settings instead
+func printVersion(w io.Writer) {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		fmt.Fprintln(w, "subsets-sum (no build info)", runtime.Version())
+		return
+	}
+
+	version := info.Main.Version
+	if version == "" {
+		version = "(devel)"
+	}
+	revision := "unknown"
+	modified := false
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			revision = s.Value
+		case "vcs.modified":
+			modified = s.Value == "true"
+		}
+	}
+	if modified {
+		revision += " (modified)"
+	}
+
+	fmt.Fprintln(w, "subsets-sum", version)
+	fmt.Fprintln(w, "go:", info.GoVersion)
+	fmt.Fprintln(w, "revision:", revision)
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// CountSumOfSquaresDivisible counts the subsets of {1,...,n} for which the sum of the squares
+// of the elements is divisible by m. i^2 modulo m only depends on i modulo m, so the elements
+// are bucketed by the residue of their square and those buckets are used as the columns
+func CountSumOfSquaresDivisible(n, m int) *big.Int {
+	counts := make([]int, m)
+	for i, count := range RangeResidueCounts(n, m) {
+		counts[(i*i)%m] += count
+	}
+	return distributionFromCounts(counts)[0]
+}
+
+// CountSignedDivisible counts the ways to give each element i of {1,...,n} a sign of +i, -i or leave it out
+// such that the signed sum is divisible by m. Each element contributes the factor (1 + x^i + x^-i), with
+// -i taken modulo m, and the 3^n choices are all counted
+func CountSignedDivisible(n, m int) *big.Int {
+	checkModulus(m)
+	checkN(n)
+
+	prev := make([]*big.Int, m)
+	next := make([]*big.Int, m)
+	for r := range prev {
+		prev[r] = new(big.Int)
+		next[r] = new(big.Int)
+	}
+	prev[0].SetInt64(1)
+
+	for i := 1; i <= n; i++ {
+		plus := i % m
+		minus := (m - plus) % m
+		for r := range next {
+			next[r].Set(prev[r])
+		}
+		for r, t := range prev {
+			next[(r+plus)%m].Add(next[(r+plus)%m], t)
+			next[(r+minus)%m].Add(next[(r+minus)%m], t)
+		}
+		prev, next = next, prev
+	}
+
+	// Check that all 3^n choices were counted
+	sum := new(big.Int)
+	for _, t := range prev {
+		sum.Add(sum, t)
+	}
+	if sum.Cmp(new(big.Int).Exp(big.NewInt(3), big.NewInt(int64(n)), nil)) != 0 {
+		panic(fmt.Errorf("%w: bad total sum", ErrInternalCheckFailed))
+	}
+	return prev[0]
+}
+
+// CountWeightedDivisible counts the subsets of {1,...,n} for which the sum of weight(i) over the elements i
+// is divisible by m. Only weight(i) modulo m matters, so the elements are bucketed by that residue and
+// the buckets are used as the columns, as in CountSumOfSquaresDivisible
+func CountWeightedDivisible(n, m int, weight func(i int) int) *big.Int {
+	checkModulus(m)
+	checkN(n)
+	counts := make([]int, m)
+	for i := 1; i <= n; i++ {
+		w := weight(i) % m
+		if w < 0 {
+			w += m
+		}
+		counts[w]++
+	}
+	return distributionFromCounts(counts)[0]
+}
+
+// CountUnderUnitScaling returns the distribution modulo m of the subset sums of {u, 2u, ..., nu}, that is of
+// {1,...,n} with every element multiplied by u, where u must be a unit modulo m. Scaling a subset scales its sum,
+// so this is the distribution of {1,...,n} with entry r moved to u*r modulo m: the entries are permuted, and
+// since u*0 = 0 the count of sums divisible by m does not change
+func CountUnderUnitScaling(n, m, u int) []*big.Int {
+	checkModulus(m)
+	checkN(n)
+	if gcd(u, m) != 1 {
+		panic(fmt.Errorf("%w: %d is not a unit modulo %d", ErrInvalidModulus, u, m))
+	}
+	scale := u % m
+	if scale < 0 {
+		scale += m
+	}
+	counts := make([]int, m)
+	for v, count := range RangeResidueCounts(n, m) {
+		counts[(v*scale)%m] += count
+	}
+	dist := distributionFromCounts(counts)
+
+	// Check it is the base distribution permuted
+	for r, t := range ResidueDistribution(n, m).Totals {
+		if dist[(r*scale)%m].Cmp(t) != 0 {
+			panic(fmt.Errorf("%w: scaled count for residue %d does not match", ErrInternalCheckFailed, r))
+		}
+	}
+	return dist
+}
+
+// CountViaResidueMap returns the distribution modulo mPrime of the subset sums of {1,...,n} with each element i
+// replaced by mapTo[i mod m]. When mapTo is a homomorphism from Z/mZ to Z/mPrimeZ this is the image of the
+// distribution modulo m, but any table is allowed. The mapped values only depend on the columns, so each column's
+// elements are moved to the column of their image, as in CountSumOfSquaresDivisible
+func CountViaResidueMap(n, m int, mapTo []int, mPrime int) []*big.Int {
+	checkModulus(m)
+	checkModulus(mPrime)
+	if len(mapTo) != m {
+		panic(fmt.Errorf("%w: expected %d residue map entries for modulus %d, got %d",
+			ErrResidueCountLengthMismatch, m, m, len(mapTo)))
+	}
+	counts := make([]int, mPrime)
+	for v, count := range RangeResidueCounts(n, m) {
+		checkResidue(mapTo[v], mPrime)
+		counts[mapTo[v]] += count
+	}
+	return distributionFromCounts(counts)
+}
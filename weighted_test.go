@@ -0,0 +1,140 @@
+package main
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func TestCountSumOfSquaresDivisibleBruteForce(t *testing.T) {
+	for n := 0; n <= 12; n++ {
+		for m := 1; m <= 9; m++ {
+			want := int64(0)
+			forEachSubset(oneTo(n), func(subset []int) {
+				s := 0
+				for _, i := range subset {
+					s += i * i
+				}
+				if s%m == 0 {
+					want++
+				}
+			})
+			if got := CountSumOfSquaresDivisible(n, m); got.Cmp(big.NewInt(want)) != 0 {
+				t.Errorf("CountSumOfSquaresDivisible(%d, %d) = %v, want %d", n, m, got, want)
+			}
+		}
+	}
+}
+
+func TestCountSignedDivisibleBruteForce(t *testing.T) {
+	for n := 0; n <= 8; n++ {
+		ways := 1
+		for i := 0; i < n; i++ {
+			ways *= 3
+		}
+		for m := 1; m <= 7; m++ {
+			// choice is a number in base 3 whose digit i is 0 to leave i+1 out, 1 for +(i+1) and 2 for -(i+1)
+			want := int64(0)
+			for choice := 0; choice < ways; choice++ {
+				sum := 0
+				for i, c := 1, choice; i <= n; i, c = i+1, c/3 {
+					switch c % 3 {
+					case 1:
+						sum += i
+					case 2:
+						sum -= i
+					}
+				}
+				if sum%m == 0 {
+					want++
+				}
+			}
+			if got := CountSignedDivisible(n, m); got.Cmp(big.NewInt(want)) != 0 {
+				t.Errorf("CountSignedDivisible(%d, %d) = %v, want %d", n, m, got, want)
+			}
+		}
+	}
+	if got, want := CountSignedDivisible(40, 1), new(big.Int).Exp(big.NewInt(3), big.NewInt(40), nil); got.Cmp(want) != 0 {
+		t.Errorf("CountSignedDivisible(40, 1) = %v, want 3^40", got)
+	}
+}
+
+func TestCountUnderUnitScaling(t *testing.T) {
+	for _, tc := range []struct{ n, m, u int }{{2000, 5, 2}, {100, 7, 3}, {30, 12, 5}, {17, 9, -2}, {10, 1, 0}, {45, 8, 11}} {
+		got := CountUnderUnitScaling(tc.n, tc.m, tc.u)
+
+		// the subsets of {u, 2u, ..., nu}, counted directly
+		c := NewCounter(tc.m)
+		for i := 1; i <= tc.n; i++ {
+			c.Add(i * tc.u)
+		}
+		if want := c.Distribution(); !sameCounts(got, want) {
+			t.Errorf("CountUnderUnitScaling(%d, %d, %d) = %v, want %v", tc.n, tc.m, tc.u, got, want)
+		}
+
+		base := ResidueDistribution(tc.n, tc.m).Totals
+		if got[0].Cmp(base[0]) != 0 {
+			t.Errorf("n = %d, m = %d: scaling by %d changed totals[0] from %v to %v", tc.n, tc.m, tc.u, base[0], got[0])
+		}
+		for r, b := range base {
+			if s := ((r*tc.u)%tc.m + tc.m) % tc.m; got[s].Cmp(b) != 0 {
+				t.Errorf("n = %d, m = %d, u = %d: residue %d moved to %d has %v, want %v", tc.n, tc.m, tc.u, r, s, got[s], b)
+			}
+		}
+	}
+
+	for _, u := range []int{0, 4, -6} {
+		if err := panicError(func() { CountUnderUnitScaling(10, 8, u) }); !errors.Is(err, ErrInvalidModulus) {
+			t.Errorf("scaling by %d modulo 8 gave %v, want ErrInvalidModulus", u, err)
+		}
+	}
+}
+
+func TestCountViaResidueMapBruteForce(t *testing.T) {
+	for _, tc := range []struct {
+		n, m   int
+		mapTo  []int
+		mPrime int
+	}{
+		// homomorphisms: reduction modulo a divisor, and multiplication into a larger group
+		{12, 6, []int{0, 1, 2, 0, 1, 2}, 3},
+		{10, 4, []int{0, 3, 6, 9}, 12},
+		// an arbitrary relabeling, and everything mapped to 0
+		{13, 5, []int{4, 0, 4, 1, 2}, 7},
+		{9, 3, []int{0, 0, 0}, 2},
+		{0, 2, []int{1, 0}, 3},
+		{3, 7, []int{6, 5, 4, 3, 2, 1, 0}, 7},
+	} {
+		mapped := make([]int, tc.n)
+		for i := range mapped {
+			mapped[i] = tc.mapTo[(i+1)%tc.m]
+		}
+		want := bruteForceDistribution(mapped, tc.mPrime)
+		if got := CountViaResidueMap(tc.n, tc.m, tc.mapTo, tc.mPrime); !sameCounts(got, want) {
+			t.Errorf("CountViaResidueMap(%d, %d, %v, %d) = %v, want %v", tc.n, tc.m, tc.mapTo, tc.mPrime, got, want)
+		}
+	}
+
+	// the identity map gives the plain distribution
+	identity := []int{0, 1, 2, 3, 4}
+	if got := CountViaResidueMap(30, 5, identity, 5); !sameCounts(got, ResidueDistribution(30, 5).Totals) {
+		t.Errorf("the identity map gave %v, want the distribution modulo 5", got)
+	}
+
+	for _, tc := range []struct {
+		mapTo []int
+		want  error
+	}{
+		{[]int{0, 1}, ErrResidueCountLengthMismatch},
+		{[]int{0, 1, 2, 3}, ErrResidueCountLengthMismatch},
+		{[]int{0, 1, 3}, ErrResidueOutOfRange},
+		{[]int{0, -1, 2}, ErrResidueOutOfRange},
+	} {
+		if err := panicError(func() { CountViaResidueMap(10, 3, tc.mapTo, 3) }); !errors.Is(err, tc.want) {
+			t.Errorf("map %v gave %v, want %v", tc.mapTo, err, tc.want)
+		}
+	}
+	if err := panicError(func() { CountViaResidueMap(10, 3, []int{0, 0, 0}, 0) }); !errors.Is(err, ErrInvalidModulus) {
+		t.Errorf("an output modulus of 0 gave %v, want %v", err, ErrInvalidModulus)
+	}
+}